@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+//ChallengeRequest describes a game to offer, either to anyone (an open challenge) or to a specific player.
+type ChallengeRequest struct {
+	Name        string
+	OpponentID  int64 //0 for an open challenge
+	Width       int
+	Height      int
+	Rules       string   //japanese, chinese, aga, korean, nz or ing
+	Komi        *float64 //nil to use the default komi for the rules and handicap
+	Handicap    int      //-1 for automatic handicap based on the ranks
+	Ranked      bool
+	Color       string //color of the challenger: automatic, black, white or random
+	TimeControl TimeControl
+	MinRanking  int //lowest rank that may accept an open challenge, on the same scale as Player.RawRanking
+	MaxRanking  int //highest rank that may accept an open challenge; 0 for no limit
+}
+
+//ChallengeCreated is returned by OGS after creating a challenge.
+//The game already exists at that point, but doesn't start until the challenge is accepted.
+type ChallengeCreated struct {
+	ChallengeID int64 `json:"challenge"`
+	GameID      int64 `json:"game"`
+}
+
+type challengePayload struct {
+	Initialized     bool          `json:"initialized"`
+	MinRanking      int           `json:"min_ranking"`
+	MaxRanking      int           `json:"max_ranking"`
+	ChallengerColor string        `json:"challenger_color"`
+	Game            challengeGame `json:"game"`
+}
+
+type challengeGame struct {
+	Name                  string               `json:"name"`
+	Rules                 string               `json:"rules"`
+	Ranked                bool                 `json:"ranked"`
+	Width                 int                  `json:"width"`
+	Height                int                  `json:"height"`
+	Handicap              int                  `json:"handicap"`
+	KomiAuto              string               `json:"komi_auto"`
+	Komi                  *float64             `json:"komi"`
+	DisableAnalysis       bool                 `json:"disable_analysis"`
+	PauseOnWeekends       bool                 `json:"pause_on_weekends"`
+	Private               bool                 `json:"private"`
+	TimeControl           string               `json:"time_control"`
+	TimeControlParameters challengeTimeControl `json:"time_control_parameters"`
+}
+
+//challengeTimeControl adds the field OGS expects the time control system in when creating a game.
+type challengeTimeControl struct {
+	TimeControl
+	TimeControlSystem string `json:"time_control"`
+}
+
+//CreateChallenge offers a new game. Directed challenges show up in the opponent's challenge list;
+//open challenges are shown to everyone on the seek graph until someone accepts them.
+func (c *Client) CreateChallenge(ctx context.Context, req ChallengeRequest) (*ChallengeCreated, error) {
+	payload := challengePayload{
+		MinRanking:      req.MinRanking,
+		MaxRanking:      req.MaxRanking,
+		ChallengerColor: req.Color,
+		Game: challengeGame{
+			Name:            req.Name,
+			Rules:           req.Rules,
+			Ranked:          req.Ranked,
+			Width:           req.Width,
+			Height:          req.Height,
+			Handicap:        req.Handicap,
+			KomiAuto:        "automatic",
+			Komi:            req.Komi,
+			PauseOnWeekends: req.TimeControl.PauseOnWeekends,
+			TimeControl:     req.TimeControl.System,
+			TimeControlParameters: challengeTimeControl{
+				TimeControl:       req.TimeControl,
+				TimeControlSystem: req.TimeControl.System,
+			},
+		},
+	}
+	if req.Komi != nil {
+		payload.Game.KomiAuto = "custom"
+	}
+	if payload.MaxRanking == 0 {
+		payload.MaxRanking = 1000
+	}
+	if payload.ChallengerColor == "" {
+		payload.ChallengerColor = "automatic"
+	}
+	path := "challenges"
+	if req.OpponentID != 0 {
+		path = fmt.Sprintf("players/%d/challenge", req.OpponentID)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var created ChallengeCreated
+	if err := c.doPostJSON(ctx, c.apiURL(), path, string(body), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+//FindPlayer looks up a player by their exact username.
+func (c *Client) FindPlayer(ctx context.Context, username string) (*Player, error) {
+	var players struct {
+		Results []Player `json:"results"`
+	}
+	if err := c.doGet(ctx, c.apiURL(), "players", url.Values{"username": {username}}, &players); err != nil {
+		return nil, err
+	}
+	for _, p := range players.Results {
+		if p.Username == username {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("Player %s not found", username)
+}
+
+//Challenge is a game offered to the logged in user, or offered by them.
+type Challenge struct {
+	ID              int64         `json:"id"`
+	Challenger      Player        `json:"challenger"`
+	Challenged      *Player       `json:"challenged"` //nil for open challenges
+	ChallengerColor string        `json:"challenger_color"`
+	Game            ChallengeGame `json:"game"`
+}
+
+//ChallengeGame holds the settings of the game a Challenge offers.
+type ChallengeGame struct {
+	ID          int64       `json:"id"`
+	Name        string      `json:"name"`
+	Rules       string      `json:"rules"`
+	Ranked      bool        `json:"ranked"`
+	Width       int         `json:"width"`
+	Height      int         `json:"height"`
+	Handicap    int         `json:"handicap"`
+	TimeControl TimeControl `json:"time_control_parameters"`
+}
+
+func (c Challenge) Description() string {
+	ranked := "unranked"
+	if c.Game.Ranked {
+		ranked = "ranked"
+	}
+	return fmt.Sprintf("from %s, %dx%d %s, %s, %s", c.Challenger, c.Game.Width, c.Game.Height, c.Game.Rules, ranked, c.Game.TimeControl)
+}
+
+//GetChallenges returns all challenges the logged in user has sent or received, fetching every page.
+//Received challenges are the ones where Challenged is the logged in user.
+func (c *Client) GetChallenges(ctx context.Context) ([]Challenge, error) {
+	var challenges []Challenge
+	var page struct {
+		Next    string      `json:"next"`
+		Results []Challenge `json:"results"`
+	}
+	err := c.doGet(ctx, c.apiURL(), "me/challenges", nil, &page)
+	for {
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, page.Results...)
+		if page.Next == "" {
+			return challenges, nil
+		}
+		next := page.Next
+		page.Next, page.Results = "", nil
+		err = c.getNext(ctx, next, &page)
+	}
+}
+
+//AcceptChallenge accepts a challenge, which starts its game. The game ID is Challenge.Game.ID.
+func (c *Client) AcceptChallenge(ctx context.Context, challengeID int64) error {
+	var response interface{} //the response body is not needed
+	return c.doPostJSON(ctx, c.apiURL(), fmt.Sprintf("me/challenges/%d/accept", challengeID), "{}", &response)
+}
+
+//DeclineChallenge declines a received challenge, or withdraws a sent one.
+func (c *Client) DeclineChallenge(ctx context.Context, challengeID int64) error {
+	var response interface{} //the response body is not needed
+	return c.doDelete(ctx, c.apiURL(), fmt.Sprintf("me/challenges/%d", challengeID), &response)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+//ChatChannel is one of the chat channels of a game.
+type ChatChannel string
+
+const (
+	//ChatMain is the chat between the players, which spectators can read as well.
+	ChatMain ChatChannel = "main"
+	//ChatMalkovich is a log that is only shown to others once the game has ended.
+	ChatMalkovich ChatChannel = "malkovich"
+	//ChatSpectator is the chat between spectators, which players can't read while the game is running.
+	ChatSpectator ChatChannel = "spectator"
+)
+
+//OnChatResult is used as a return for the OnChat callback event.
+type OnChatResult struct {
+	Channel ChatChannel `json:"channel"`
+	Line    ChatLine    `json:"line"`
+}
+
+//ChatLine is a single chat message.
+type ChatLine struct {
+	ChatID     string   `json:"chat_id"`
+	PlayerID   int64    `json:"player_id"`
+	Username   string   `json:"username"`
+	Body       ChatBody `json:"body"`
+	Date       int64    `json:"date"` //seconds since epoch
+	MoveNumber int      `json:"move_number"`
+}
+
+//Time returns the time the message was sent.
+func (l ChatLine) Time() time.Time {
+	return time.Unix(l.Date, 0)
+}
+
+//ChatBody is the text of a chat message. Besides plain text, OGS can send structured messages
+//like shared variations; those are reduced to a short description of their type.
+type ChatBody string
+
+func (b *ChatBody) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = ChatBody(text)
+		return nil
+	}
+	var structured struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &structured); err != nil {
+		return err
+	}
+	if structured.Name != "" {
+		*b = ChatBody(fmt.Sprintf("[%s: %s]", structured.Type, structured.Name))
+	} else {
+		*b = ChatBody(fmt.Sprintf("[%s]", structured.Type))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+//PlayerClock is the time left for one player, in seconds. Which fields are used depends on the time control system:
+//all systems use ThinkingTime (the main time); byo-yomi adds Periods of PeriodTime each, Canadian adds MovesLeft
+//stones to play in BlockTime, and Fischer may set SkipBonus when no increment is given for the current move.
+//PeriodTimeLeft is the time left in the current byo-yomi period.
+type PlayerClock struct {
+	ThinkingTime   float64 `json:"thinking_time"`
+	Periods        int     `json:"periods"`
+	PeriodTime     float64 `json:"period_time"`
+	PeriodTimeLeft float64 `json:"period_time_left"`
+	MovesLeft      int     `json:"moves_left"`
+	BlockTime      float64 `json:"block_time"`
+	SkipBonus      bool    `json:"skip_bonus"`
+}
+
+//UnmarshalJSON also accepts a plain number, which OGS sends for time systems that only have a single timer.
+func (p *PlayerClock) UnmarshalJSON(data []byte) error {
+	type playerClock PlayerClock //prevents recursion
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*p = PlayerClock{ThinkingTime: seconds}
+		return nil
+	}
+	if err := json.Unmarshal(data, (*playerClock)(p)); err != nil {
+		return err
+	}
+	if p.PeriodTimeLeft == 0 {
+		p.PeriodTimeLeft = p.PeriodTime
+	}
+	return nil
+}
+
+//Elapse returns the clock after the player has thought for d under the given time control system.
+//Once main time runs out, byo-yomi periods are used up one by one and Canadian block time starts counting down.
+//A clock that has run out entirely has no time left in any field.
+func (p PlayerClock) Elapse(system string, d time.Duration) PlayerClock {
+	left := p.ThinkingTime - d.Seconds()
+	if left >= 0 {
+		p.ThinkingTime = left
+		return p
+	}
+	over := -left
+	p.ThinkingTime = 0
+	switch system {
+	case "byoyomi":
+		if over < p.PeriodTimeLeft {
+			p.PeriodTimeLeft -= over
+			return p
+		}
+		over -= p.PeriodTimeLeft
+		if p.PeriodTime <= 0 {
+			p.Periods, p.PeriodTimeLeft = 0, 0
+			return p
+		}
+		used := 1 + int(over/p.PeriodTime)
+		if used >= p.Periods {
+			p.Periods, p.PeriodTimeLeft = 0, 0
+			return p
+		}
+		p.Periods -= used
+		p.PeriodTimeLeft = p.PeriodTime - math.Mod(over, p.PeriodTime)
+	case "canadian":
+		p.BlockTime = math.Max(0, p.BlockTime-over)
+	}
+	return p
+}
+
+//Remaining returns how long the player can keep thinking before losing on time, under the given time control system.
+func (p PlayerClock) Remaining(system string) time.Duration {
+	seconds := p.ThinkingTime
+	switch system {
+	case "byoyomi":
+		if p.Periods > 0 {
+			seconds += p.PeriodTimeLeft + float64(p.Periods-1)*p.PeriodTime
+		}
+	case "canadian":
+		seconds += p.BlockTime
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+//Format returns the clock as text, e.g. "9:32 + 5x0:30" for byo-yomi.
+func (p PlayerClock) Format(system string) string {
+	main := formatSeconds(int(math.Ceil(p.ThinkingTime)))
+	switch system {
+	case "byoyomi":
+		if p.ThinkingTime > 0 {
+			return fmt.Sprintf("%s + %dx%s", main, p.Periods, formatSeconds(int(p.PeriodTime)))
+		}
+		return fmt.Sprintf("%s (%d left)", formatSeconds(int(math.Ceil(p.PeriodTimeLeft))), p.Periods)
+	case "canadian":
+		if p.ThinkingTime > 0 {
+			return fmt.Sprintf("%s + %s/%d", main, formatSeconds(int(p.BlockTime)), p.MovesLeft)
+		}
+		return fmt.Sprintf("%s/%d", formatSeconds(int(math.Ceil(p.BlockTime))), p.MovesLeft)
+	}
+	return main
+}
+
+//TimeLeft returns the clocks of both players at time now, counting down the clock of the player whose turn it is.
+//Paused clocks are not counted down. serverOffset is the difference between the server clock and the local clock,
+//which can be estimated as ServerOffset(received) when the event is received.
+func (c *OnClockResult) TimeLeft(system string, now time.Time, serverOffset time.Duration) (black, white PlayerClock) {
+	black, white = c.BlackTime, c.WhiteTime
+	if c.PausedSince != 0 || c.LastMove == 0 {
+		return
+	}
+	elapsed := now.Add(serverOffset).Sub(time.UnixMilli(c.LastMove))
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	switch c.CurrentPlayerID {
+	case c.BlackPlayerID:
+		black = black.Elapse(system, elapsed)
+	case c.WhitePlayerID:
+		white = white.Elapse(system, elapsed)
+	}
+	return
+}
+
+//ServerOffset returns how far the server clock was ahead of the local clock when the event was received.
+//It is 0 if the event contained no server time.
+func (c *OnClockResult) ServerOffset(received time.Time) time.Duration {
+	if c.Now == 0 {
+		return 0
+	}
+	return time.UnixMilli(c.Now).Sub(received)
+}
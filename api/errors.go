@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	//ErrUnauthorized matches (through errors.Is) any OGSApiError caused by missing or expired credentials.
+	ErrUnauthorized = errors.New("Not authorized; please log in again")
+	//ErrOffline is returned by RealtimeClient emits while the connection to the realtime server is down.
+	ErrOffline = errors.New("Not connected to the realtime server")
+)
+
+//NetworkError is returned when a request could not be sent or no response was received,
+//including when the request's context was cancelled.
+type NetworkError struct {
+	Path string
+	Err  error
+}
+
+func (n *NetworkError) Error() string {
+	return fmt.Sprintf("Error calling /%s: %s", n.Path, n.Err)
+}
+
+func (n *NetworkError) Unwrap() error {
+	return n.Err
+}
+
+//OGSApiError is returned on non-2xx return codes from the online-go API.
+//Detail contains the error message from the response body, if OGS provided one.
+type OGSApiError struct {
+	Code   int
+	Status string
+	Path   string
+	Detail string
+	Body   []byte
+}
+
+func (o *OGSApiError) Error() string {
+	if o.Detail != "" {
+		return fmt.Sprintf("Error calling /%s: %s: %s", o.Path, o.Status, o.Detail)
+	}
+	return fmt.Sprintf("Error calling /%s: %s", o.Path, o.Status)
+}
+
+//Is makes errors.Is(err, ErrUnauthorized) true for 401 responses.
+func (o *OGSApiError) Is(target error) bool {
+	return target == ErrUnauthorized && o.Code == 401
+}
+
+//DecodeError is returned when OGS answered successfully, but the response could not be decoded.
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (d *DecodeError) Error() string {
+	return fmt.Sprintf("Invalid response from /%s: %s", d.Path, d.Err)
+}
+
+func (d *DecodeError) Unwrap() error {
+	return d.Err
+}
+
+//newOGSApiError builds an OGSApiError, extracting the error message from the various
+//formats OGS uses for error bodies.
+func newOGSApiError(code int, status, path string, body []byte) *OGSApiError {
+	o := &OGSApiError{Code: code, Status: status, Path: path, Body: body}
+	var errBody struct {
+		Detail           string `json:"detail"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		Message          string `json:"message"`
+	}
+	if json.Unmarshal(body, &errBody) == nil {
+		for _, msg := range []string{errBody.Detail, errBody.ErrorDescription, errBody.Message, errBody.Error} {
+			if msg != "" {
+				o.Detail = msg
+				break
+			}
+		}
+	}
+	return o
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//GameDetails unmarshals the api/v1/games/<id> endpoint, which contains everything known about a game.
+//The game record itself (moves, initial state) is in GameData.
+type GameDetails struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Players struct {
+		Black Player `json:"black"`
+		White Player `json:"white"`
+	} `json:"players"`
+	Width       int         `json:"width"`
+	Height      int         `json:"height"`
+	Rules       string      `json:"rules"` //japanese, chinese, aga, korean, ing or nz
+	Ranked      bool        `json:"ranked"`
+	Handicap    int         `json:"handicap"`
+	Komi        float64     `json:"-"` //sent as a string by OGS, see UnmarshalJSON
+	TimeControl TimeControl `json:"time_control_parameters"`
+	Started     time.Time   `json:"started"`
+	Ended       *time.Time  `json:"ended"` //nil for games in progress
+	Outcome     string      `json:"outcome"`
+	BlackLost   bool        `json:"black_lost"`
+	WhiteLost   bool        `json:"white_lost"`
+	Annulled    bool        `json:"annulled"`
+	GameData    GameData    `json:"gamedata"`
+}
+
+func (g *GameDetails) UnmarshalJSON(data []byte) error {
+	type gameDetails GameDetails //prevents recursion
+	aux := struct {
+		*gameDetails
+		Komi json.Number `json:"komi"`
+	}{gameDetails: (*gameDetails)(g)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Komi != "" {
+		komi, err := aux.Komi.Float64()
+		if err != nil {
+			return err
+		}
+		g.Komi = komi
+	}
+	return nil
+}
+
+//Result returns the result in the usual B+R/W+3.5 style notation, or an empty string if the game hasn't ended.
+func (g *GameDetails) Result() string {
+	if g.Ended == nil {
+		return ""
+	}
+	if g.Annulled || (g.BlackLost && g.WhiteLost) {
+		return "Void"
+	}
+	if !g.BlackLost && !g.WhiteLost {
+		return "0" //jigo
+	}
+	winner := "B"
+	if !g.WhiteLost {
+		winner = "W"
+	}
+	switch {
+	case g.Outcome == "Resignation":
+		return winner + "+R"
+	case g.Outcome == "Timeout":
+		return winner + "+T"
+	case g.Outcome == "Disqualification":
+		return winner + "+F"
+	case strings.HasSuffix(g.Outcome, " points"):
+		return winner + "+" + strings.TrimSuffix(g.Outcome, " points")
+	}
+	return winner + "+"
+}
+
+//Description returns a multi-line summary of the game settings, for display purposes.
+//Do not rely on this string remaining stable.
+func (g *GameDetails) Description() string {
+	ranked := "unranked"
+	if g.Ranked {
+		ranked = "ranked"
+	}
+	desc := fmt.Sprintf("Black: %s\nWhite: %s\n%dx%d, %s rules, komi %.1f, handicap %d, %s\nTime: %s\nStarted: %s",
+		g.Players.Black, g.Players.White, g.Width, g.Height, g.Rules, g.Komi, g.Handicap, ranked,
+		g.TimeControl, g.Started.Local().Format("2006-01-02 15:04"))
+	if g.Ended != nil {
+		desc += fmt.Sprintf("\nEnded: %s (%s)", g.Ended.Local().Format("2006-01-02 15:04"), g.Result())
+	}
+	return desc
+}
+
+//GameData is the game record as used by OGS. It is sent by the realtime API in the "game/<id>/gamedata" event,
+//returned by the termination API and included in GameDetails. Not every source fills in every field.
+//The board position can be reconstructed by placing InitialState and playing Moves.
+type GameData struct {
+	GameID   int64  `json:"game_id"`
+	GameName string `json:"game_name"`
+	Phase    string `json:"phase"` //play, stone removal or finished
+	Players  struct {
+		Black Player `json:"black"`
+		White Player `json:"white"`
+	} `json:"players"`
+	BlackPlayerID         int64   `json:"black_player_id"`
+	WhitePlayerID         int64   `json:"white_player_id"`
+	Width                 int     `json:"width"`
+	Height                int     `json:"height"`
+	InitialPlayer         string  `json:"initial_player"`
+	Handicap              int     `json:"handicap"`
+	FreeHandicapPlacement bool    `json:"free_handicap_placement"`
+	Komi                  float64 `json:"komi"`
+	Rules                 string  `json:"rules"`
+	SuperkoAlgorithm      string  `json:"superko_algorithm"` //e.g. psk (positional) or ssk (situational), empty if unknown
+	Ranked                bool    `json:"ranked"`
+	InitialState          struct {
+		Black string `json:"black"` //SGF coordinates, two letters per stone
+		White string `json:"white"`
+	} `json:"initial_state"`
+	Moves         []Move                     `json:"moves"`
+	TimeControl   TimeControl                `json:"time_control"`
+	Clock         OnClockResult              `json:"clock"`
+	Removed       string                     `json:"removed"`        //SGF coordinates of stones marked dead during stone removal
+	Score         *Score                     `json:"score"`          //only set once the game is scored
+	UndoRequested int                        `json:"undo_requested"` //move number an undo was requested for, 0 if none
+	PausedSince   int64                      `json:"paused_since"`   //ms since epoch, 0 if not paused
+	PauseControl  map[string]json.RawMessage `json:"pause_control"`  //reasons the game is paused, e.g. "weekend" or "vacation-<id>"
+	Outcome       string                     `json:"outcome"`
+	Winner        int64                      `json:"winner"`     //player ID of the winner, 0 if there is none (yet)
+	StartTime     int64                      `json:"start_time"` //seconds since epoch
+	EndTime       int64                      `json:"end_time"`   //seconds since epoch, 0 if the game hasn't ended
+}
+
+//Score is the final score of a game, per player.
+type Score struct {
+	Black PlayerScore `json:"black"`
+	White PlayerScore `json:"white"`
+}
+
+//PlayerScore contains the score of one player. Which parts count depends on the rules of the game.
+type PlayerScore struct {
+	Total            float64 `json:"total"`
+	Stones           int     `json:"stones"`
+	Territory        int     `json:"territory"`
+	Prisoners        int     `json:"prisoners"`
+	Handicap         int     `json:"handicap"`
+	Komi             float64 `json:"komi"`
+	ScoringPositions string  `json:"scoring_positions"` //SGF coordinates
+}
+
+//Finished returns true if the game has ended.
+func (g *GameData) Finished() bool {
+	return g.Phase == "finished"
+}
+
+//Paused returns true if the game clock is currently paused, for any reason.
+func (g *GameData) Paused() bool {
+	return g.PausedSince != 0 || len(g.PauseControl) > 0
+}
+
+//ColorForMove(i) Get whether move i (counting from 0) belongs to "black" or "white" (true = black, false = white)
+func (g *GameData) ColorForMove(i int) bool {
+	initialPlayer := g.InitialPlayer != "white"
+	// If an uneven number of moves is made, it's the other player's turn.
+	// If free_handicap_placement is true, then the initial player gets g.Handicap moves
+	// If free_handicap_placement is false, GameData.InitialState is populated instead
+	// with a string describing coordinates in SGF notation.
+	// A handicap of 1 essentially does nothing
+	initialExtraMoves := 0
+	if g.FreeHandicapPlacement && g.Handicap > 1 {
+		initialExtraMoves = g.Handicap - 1
+	}
+	if i <= initialExtraMoves {
+		return initialPlayer
+	}
+	if (i-initialExtraMoves)%2 == 1 {
+		//It is the other player's turn
+		return !initialPlayer
+	}
+	return initialPlayer
+}
+
+//Move is a single move in a game record. X and Y are -1 for a pass.
+//Time is the number of milliseconds the player spent on the move.
+type Move struct {
+	X    int
+	Y    int
+	Time int64
+}
+
+//UnmarshalJSON reads OGS moves, which are arrays like [x, y, time] with optional trailing elements.
+func (m *Move) UnmarshalJSON(data []byte) error {
+	var v []json.RawMessage
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v) < 2 {
+		return fmt.Errorf("invalid move: %s", data)
+	}
+	var x, y, t float64
+	if err := json.Unmarshal(v[0], &x); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(v[1], &y); err != nil {
+		return err
+	}
+	if len(v) > 2 {
+		//may be null for moves made before timing was recorded
+		json.Unmarshal(v[2], &t)
+	}
+	m.X, m.Y, m.Time = int(x), int(y), int64(t)
+	return nil
+}
+
+//IsPass returns true if the move is a pass.
+func (m Move) IsPass() bool {
+	return m.X < 0 || m.Y < 0
+}
+
+//TimeControl describes the time settings of a game. Which fields are used depends on System:
+//"byoyomi" uses MainTime, PeriodTime and Periods; "fischer" uses InitialTime, TimeIncrement and MaxTime;
+//"canadian" uses MainTime, PeriodTime and StonesPerPeriod; "simple" uses PerMove; "absolute" uses TotalTime.
+//All times are in seconds.
+type TimeControl struct {
+	System          string `json:"system"`
+	Speed           string `json:"speed"` //blitz, live or correspondence
+	MainTime        int    `json:"main_time"`
+	PeriodTime      int    `json:"period_time"`
+	Periods         int    `json:"periods"`
+	StonesPerPeriod int    `json:"stones_per_period"`
+	InitialTime     int    `json:"initial_time"`
+	TimeIncrement   int    `json:"time_increment"`
+	MaxTime         int    `json:"max_time"`
+	PerMove         int    `json:"per_move"`
+	TotalTime       int    `json:"total_time"`
+	PauseOnWeekends bool   `json:"pause_on_weekends"`
+}
+
+//UnmarshalJSON accepts both a JSON object and a string containing a JSON object,
+//as api/v1/games/<id> sends the time control parameters as an encoded string.
+func (t *TimeControl) UnmarshalJSON(data []byte) error {
+	type timeControl TimeControl //prevents recursion
+	if len(data) > 0 && data[0] == '"' {
+		str, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		data = []byte(str)
+	}
+	if string(data) == "null" || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, (*timeControl)(t))
+}
+
+func (t TimeControl) String() string {
+	switch t.System {
+	case "byoyomi":
+		return fmt.Sprintf("Byo-yomi, %s + %dx%s", formatSeconds(t.MainTime), t.Periods, formatSeconds(t.PeriodTime))
+	case "fischer":
+		return fmt.Sprintf("Fischer, %s + %s per move, max %s", formatSeconds(t.InitialTime), formatSeconds(t.TimeIncrement), formatSeconds(t.MaxTime))
+	case "canadian":
+		return fmt.Sprintf("Canadian, %s + %s per %d stones", formatSeconds(t.MainTime), formatSeconds(t.PeriodTime), t.StonesPerPeriod)
+	case "simple":
+		return fmt.Sprintf("Simple, %s per move", formatSeconds(t.PerMove))
+	case "absolute":
+		return fmt.Sprintf("Absolute, %s", formatSeconds(t.TotalTime))
+	case "none", "":
+		return "None"
+	}
+	return t.System
+}
+
+//formatSeconds formats a duration in seconds as e.g. 1d 2:03:04, 2:03:04 or 3:04.
+func formatSeconds(s int) string {
+	days, s := s/86400, s%86400
+	h, m, s := s/3600, (s%3600)/60, s%60
+	switch {
+	case days > 0 && h == 0 && m == 0 && s == 0:
+		return fmt.Sprintf("%dd", days)
+	case days > 0:
+		return fmt.Sprintf("%dd %d:%02d:%02d", days, h, m, s)
+	case h > 0:
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+//GameStatus selects games by whether they have ended.
+type GameStatus int
+
+const (
+	AllGames GameStatus = iota
+	ActiveGames
+	EndedGames
+)
+
+func (s GameStatus) String() string {
+	switch s {
+	case ActiveGames:
+		return "active"
+	case EndedGames:
+		return "ended"
+	default:
+		return "all"
+	}
+}
+
+//GameQuery filters the games returned by Client.Games. Zero values don't filter.
+//Size and date filters are sent to OGS, but are also checked locally, as is OpponentID; a page may
+//therefore contain fewer games than requested, or even none at all while more pages are available.
+type GameQuery struct {
+	Status        GameStatus
+	OpponentID    int64
+	Width         int
+	Height        int
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	PageSize      int //defaults to the OGS page size
+}
+
+func (q GameQuery) values() url.Values {
+	var values url.Values = make(url.Values)
+	switch q.Status {
+	case ActiveGames:
+		values.Set("ended__isnull", "true")
+	case EndedGames:
+		values.Set("ended__isnull", "false")
+	}
+	if q.Width > 0 {
+		values.Set("width", fmt.Sprint(q.Width))
+	}
+	if q.Height > 0 {
+		values.Set("height", fmt.Sprint(q.Height))
+	}
+	if !q.StartedAfter.IsZero() {
+		values.Set("started__gte", q.StartedAfter.UTC().Format(time.RFC3339))
+	}
+	if !q.StartedBefore.IsZero() {
+		values.Set("started__lt", q.StartedBefore.UTC().Format(time.RFC3339))
+	}
+	if q.PageSize > 0 {
+		values.Set("page_size", fmt.Sprint(q.PageSize))
+	}
+	//newest games first
+	values.Set("ordering", "-id")
+	return values
+}
+
+func (q GameQuery) matches(g GameListData) bool {
+	switch {
+	case q.Status == ActiveGames && g.Ended != nil,
+		q.Status == EndedGames && g.Ended == nil,
+		q.Width > 0 && g.Width != q.Width,
+		q.Height > 0 && g.Height != q.Height,
+		!q.StartedAfter.IsZero() && g.Started.Before(q.StartedAfter),
+		!q.StartedBefore.IsZero() && !g.Started.Before(q.StartedBefore):
+		return false
+	}
+	if q.OpponentID != 0 {
+		for _, p := range g.Players {
+			if p.ID == q.OpponentID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+//GamePager pages through the games of the logged in user, following the "next" links returned by OGS.
+//Create one with Client.Games.
+type GamePager struct {
+	client  *Client
+	query   GameQuery
+	next    string
+	started bool
+	count   int
+}
+
+//Games returns a GamePager over the games of the logged in user that match q, newest first.
+//No request is made until Next is called.
+func (c *Client) Games(q GameQuery) *GamePager {
+	return &GamePager{client: c, query: q}
+}
+
+//HasNext returns true if there may be more games to fetch.
+func (p *GamePager) HasNext() bool {
+	return !p.started || p.next != ""
+}
+
+//Count returns the number of games OGS reported for the server-side filters, once the first page is fetched.
+func (p *GamePager) Count() int {
+	return p.count
+}
+
+//Next fetches the next page of games. It returns an empty list if there are no more pages.
+func (p *GamePager) Next(ctx context.Context) ([]GameListData, error) {
+	if !p.HasNext() {
+		return nil, nil
+	}
+	var gamelist GameList
+	var err error
+	if !p.started {
+		err = p.client.doGet(ctx, p.client.apiURL(), "me/games", p.query.values(), &gamelist)
+	} else {
+		err = p.client.getNext(ctx, p.next, &gamelist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.started = true
+	p.next = gamelist.Next
+	p.count = gamelist.Count
+
+	games := make([]GameListData, 0, len(gamelist.Games))
+	for _, g := range gamelist.Games {
+		if p.query.matches(g) {
+			games = append(games, g)
+		}
+	}
+	return games, nil
+}
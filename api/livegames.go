@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+//LiveGameQuery filters the public games returned by RealtimeClient.LiveGames. Zero values don't filter.
+//OGS can only filter on the common board sizes, so Size must be 9, 13 or 19 to have an effect.
+//Ranks are checked locally, so a page may contain fewer games than Limit.
+type LiveGameQuery struct {
+	Correspondence bool    //list correspondence games instead of live games
+	Size           int     //9, 13 or 19
+	MinRank        float32 //both players must be at least this rank, on the same scale as Player.RawRanking
+	MaxRank        float32 //both players must be at most this rank
+	From           int     //index of the first game to return
+	Limit          int     //defaults to 20
+}
+
+//EmitGameListQuery asks for a page of the public game list. It is answered with a LiveGameList.
+type EmitGameListQuery struct {
+	List   string        `json:"list"` //"live" or "corr"
+	SortBy string        `json:"sort_by"`
+	Where  GameListWhere `json:"where"`
+	From   int           `json:"from"`
+	Limit  int           `json:"limit"`
+}
+
+//GameListWhere holds the server-side filters of EmitGameListQuery.
+type GameListWhere struct {
+	Hide9x9   bool `json:"hide_9x9,omitempty"`
+	Hide13x13 bool `json:"hide_13x13,omitempty"`
+	Hide19x19 bool `json:"hide_19x19,omitempty"`
+	HideOther bool `json:"hide_other,omitempty"`
+}
+
+//LiveGameList is a page of the public game list.
+type LiveGameList struct {
+	List    string     `json:"list"`
+	SortBy  string     `json:"by"`
+	Size    int        `json:"size"` //total number of games in the list, before rank filtering
+	From    int        `json:"from"`
+	Limit   int        `json:"limit"`
+	Results []LiveGame `json:"results"`
+
+	Returned int `json:"-"` //number of games OGS returned, before rank filtering
+}
+
+//LiveGame is a game in the public game list.
+type LiveGame struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Black       Player `json:"black"`
+	White       Player `json:"white"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	MoveNumber  int    `json:"move_number"`
+	Phase       string `json:"phase"`
+	TimePerMove int    `json:"time_per_move"` //average seconds per move for the time control
+}
+
+func (g LiveGame) Description() string {
+	return fmt.Sprintf("%s (B) vs %s (W) (%dx%d), move %d", g.Black, g.White, g.Width, g.Height, g.MoveNumber)
+}
+
+func (q LiveGameQuery) args() *EmitGameListQuery {
+	args := &EmitGameListQuery{
+		List:   "live",
+		SortBy: "rank",
+		From:   q.From,
+		Limit:  q.Limit,
+	}
+	if q.Correspondence {
+		args.List = "corr"
+	}
+	if args.Limit <= 0 {
+		args.Limit = 20
+	}
+	if q.Size != 0 {
+		args.Where = GameListWhere{
+			Hide9x9:   q.Size != 9,
+			Hide13x13: q.Size != 13,
+			Hide19x19: q.Size != 19,
+			HideOther: true,
+		}
+	}
+	return args
+}
+
+func (q LiveGameQuery) matches(g LiveGame) bool {
+	for _, p := range []Player{g.Black, g.White} {
+		if q.MinRank > 0 && p.RawRanking < q.MinRank || q.MaxRank > 0 && p.RawRanking > q.MaxRank {
+			return false
+		}
+	}
+	return q.Size == 0 || g.Width == q.Size && g.Height == q.Size
+}
+
+//LiveGames returns a page of the public games that are currently being played, strongest players first.
+//Use LiveGameList.Size and LiveGameQuery.From to get the other pages.
+func (r *RealtimeClient) LiveGames(ctx context.Context, q LiveGameQuery) (*LiveGameList, error) {
+	var list LiveGameList
+	if err := r.ack(ctx, "gamelist/query", q.args(), &list); err != nil {
+		return nil, err
+	}
+	list.Returned = len(list.Results)
+	results := list.Results[:0]
+	for _, g := range list.Results {
+		if q.matches(g) {
+			results = append(results, g)
+		}
+	}
+	list.Results = results
+	return &list, nil
+}
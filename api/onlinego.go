@@ -1,10 +1,13 @@
 //Package api contains methods to interact with the online-go.com REST API and Realtime API.
-//For methods that require authentication, populate api.OauthClientID and call AuthenticatePassword
-//or AuthenticateRefreshToken first before using the rest of the API. If successful, AuthData will contain
-//relevant information about the user and tokens.
+//All calls go through a Client, which is created with NewClient. For methods that require authentication,
+//call Client.AuthenticatePassword or Client.AuthenticateRefreshToken first before using the rest of the API.
+//If successful, Client.AuthData will contain relevant information about the user and tokens.
+//Several clients can be used side by side, e.g. for multiple accounts or for different OGS servers.
+//Every call that touches the network takes a context.Context, which can be used to cancel it or set a deadline.
 package api
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"errors"
@@ -14,34 +17,75 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/lvank/termsuji/sgf"
 )
 
 var (
 	//go:embed client_id.txt
 	oauthClientIDRaw string //may contain whitespace or other characters; use the exported one instead
 	OauthClientID    = strings.TrimSpace(oauthClientIDRaw)
-	BaseURL          = "https://online-go.com"
-	AuthData         UserInfo
 
 	//errors
 	InvalidRefreshToken = errors.New("Invalid refresh token")
-
-	apiURL                  = fmt.Sprintf("%s/api/v1/", BaseURL)
-	termApiURL              = fmt.Sprintf("%s/termination-api/", BaseURL)
-	oauthURL                = fmt.Sprintf("%s/oauth2/", BaseURL)
-	client     *http.Client = &http.Client{}
-	//Rune indices for lower/uppercase a/z, used for sgf string conversion
-	rAL, rZL, rAU, rZU int = int('a'), int('z'), int('A'), int('Z')
 )
 
-//OGSApiError is returned on non-200 return codes from the online-go API.
-type OGSApiError struct {
-	Code int
-	err  error
+//DefaultBaseURL is the OGS server used by NewClient.
+const DefaultBaseURL = "https://online-go.com"
+
+//Client talks to a single OGS server on behalf of a single user. It owns its own HTTP client,
+//OAuth credentials and player info, so multiple clients can be used independently of each other.
+//BaseURL may be changed at any time (e.g. to "https://beta.online-go.com"); all endpoints are derived from it.
+//
+//Access tokens are refreshed automatically when they are about to expire or when OGS rejects them.
+//Set OnTokenRefresh to get notified of the new tokens, e.g. to store the rotated refresh token.
+type Client struct {
+	BaseURL        string
+	ClientID       string //OAuth client ID, defaults to OauthClientID
+	HTTPClient     *http.Client
+	AuthData       UserInfo
+	OnTokenRefresh func(OauthResponse)
+
+	authMu     sync.Mutex //guards AuthData and token refreshes
+	realtimeMu sync.Mutex //guards realtime
+	realtime   *RealtimeClient
+}
+
+//tokenRefreshMargin is how long before expiry an access token is refreshed.
+const tokenRefreshMargin = time.Minute
+
+//NewClient returns a Client for DefaultBaseURL using the embedded OAuth client ID.
+func NewClient() *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		ClientID:   OauthClientID,
+		HTTPClient: &http.Client{},
+	}
+}
+
+func (c *Client) apiURL() string {
+	return fmt.Sprintf("%s/api/v1/", c.BaseURL)
 }
 
-func (o *OGSApiError) Error() string {
-	return o.err.Error()
+func (c *Client) termApiURL() string {
+	return fmt.Sprintf("%s/termination-api/", c.BaseURL)
+}
+
+func (c *Client) oauthURL() string {
+	return fmt.Sprintf("%s/oauth2/", c.BaseURL)
+}
+
+//realtimeURL returns the socket.io websocket URL belonging to BaseURL.
+func (c *Client) realtimeURL() string {
+	host := c.BaseURL
+	scheme := "wss://"
+	if strings.HasPrefix(host, "http://") {
+		scheme = "ws://"
+	}
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	return fmt.Sprintf("%s%s/socket.io/?EIO=3&transport=websocket", scheme, strings.TrimSuffix(host, "/"))
 }
 
 //OauthResponse is returned by the oauth/token endpoint of OGS.
@@ -55,22 +99,25 @@ type OauthResponse struct {
 	ErrorDescription string `json:"error_description"`
 }
 
-func (o *OauthResponse) GetError() string {
+//GetError returns the error from the response, or nil if there was none.
+func (o *OauthResponse) GetError() error {
 	if o.Error != "" {
 		if o.ErrorDescription != "" {
-			return o.ErrorDescription
+			return errors.New(o.ErrorDescription)
 		}
 		//Oauth error with no description is likely misconfiguration
-		panic(o.Error)
+		return fmt.Errorf("OAuth error: %s (is the OAuth client ID correct?)", o.Error)
 	}
-	return ""
+	return nil
 }
 
 //UserInfo contains info about the logged in user after calling either Authenticate function.
+//Expiry is the time at which Oauth.AccessToken stops being valid.
 type UserInfo struct {
 	Authenticated bool
 	Player        Player
 	Oauth         OauthResponse
+	Expiry        time.Time
 }
 
 //Player information
@@ -80,22 +127,49 @@ type Player struct {
 	RawRanking float32 `json:"ranking"`
 }
 
+//UnmarshalJSON also accepts "rank" for the ranking, which is what the realtime API uses.
+func (p *Player) UnmarshalJSON(data []byte) error {
+	type player Player //prevents recursion
+	aux := struct {
+		*player
+		Rank *float32 `json:"rank"`
+	}{player: (*player)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Rank != nil && p.RawRanking == 0 {
+		p.RawRanking = *aux.Rank
+	}
+	return nil
+}
+
 func (p Player) String() string {
 	return fmt.Sprintf("%s (%s)", p.Username, p.Ranking())
 }
 
+//adds 0.5 to round to nearest integer instead of rounding 1.9 to 1
+const rankRounding = 0.5
+
 func (p Player) Ranking() string {
-	//adds 0.5 to round to nearest integer instead of rounding 1.9 to 1
 	if p.RawRanking < 30 {
-		return fmt.Sprintf("%d kyu", int(30-p.RawRanking+0.5))
+		return fmt.Sprintf("%d kyu", int(30-p.RawRanking+rankRounding))
 	} else {
-		return fmt.Sprintf("%d dan", int((p.RawRanking-30+0.5)+1))
+		return fmt.Sprintf("%d dan", int((p.RawRanking-30+rankRounding)+1))
 	}
 }
 
+//MaxRawRankingForKyu returns the highest RawRanking that Ranking shows as the given kyu rank.
+//Anything above it is shown as a stronger rank.
+func MaxRawRankingForKyu(kyu int) float32 {
+	return 30 + rankRounding - float32(kyu)
+}
+
 //Game information
 type GameList struct {
-	Games []GameListData `json:"results"`
+	Count    int            `json:"count"`
+	Next     string         `json:"next"`     //URL of the next page, empty on the last page
+	Previous string         `json:"previous"` //URL of the previous page, empty on the first page
+	Games    []GameListData `json:"results"`
 }
 
 //GameListData contains data from the current games endpoint. This does not contain game details;
@@ -108,19 +182,8 @@ type GameListData struct {
 	Players   map[string]Player `json:"players"`
 	BlackLost bool              `json:"black_lost"`
 	WhiteLost bool              `json:"white_lost"`
-}
-
-//BoardData is a currently unused struct used to unmarshal the older api/v1/games/<id> endpoint.
-//It requires reconstructing the game state based on Moves, which is incomplete.
-//Use BoardState and its related functions instead.
-type BoardData struct {
-	Width                 int               `json:"width"`
-	Height                int               `json:"height"`
-	InitialPlayer         string            `json:"initial_player"`
-	Handicap              int               `json:"handicap"`
-	FreeHandicapPlacement bool              `json:"free_handicap_placement"`
-	InitialState          map[string]string `json:"initial_state"`
-	Moves                 []BoardPos        `json:"moves"`
+	Started   time.Time         `json:"started"`
+	Ended     *time.Time        `json:"ended"` //nil for games in progress
 }
 
 //BoardState unmarshals the termination-api/game endpoint. In OGS, Board is represented as a 2D array,
@@ -142,6 +205,11 @@ func (b *BoardState) Finished() bool {
 	return b.Phase == "finished"
 }
 
+//RemovedAt returns true if the stone at x, y is marked as dead.
+func (b *BoardState) RemovedAt(x, y int) bool {
+	return y >= 0 && y < len(b.Removal) && x >= 0 && x < len(b.Removal[y]) && b.Removal[y][x] > 0
+}
+
 func (b *BoardState) Height() int {
 	return len(b.Board)
 }
@@ -153,30 +221,6 @@ func (b *BoardState) Width() int {
 	return len(b.Board[0])
 }
 
-//ColorForMove(i) Get whether move i belongs to "black" or "white" (true = black, false = white)
-//This is part of the unfinished BoardData struct and should not be relied on, but can serve
-//as a starting point if you're considering using it.
-func (b *BoardData) ColorForMove(i int) bool {
-	initialPlayer := b.InitialPlayer == "black"
-	// If an uneven number of moves is made, it's the other player's turn.
-	// If free_handicap_placement is true, then the initial player gets g.Handicap moves
-	// If free_handicap_placement is false, GameData.InitialState is populated instead
-	// with a string describing coordinates in SGF notation.
-	// A handicap of 1 essentially does nothing
-	initialExtraMoves := 0
-	if b.FreeHandicapPlacement {
-		initialExtraMoves = b.Handicap - 1
-	}
-	if i <= initialExtraMoves {
-		return initialPlayer
-	}
-	if (i-initialExtraMoves)%2 == 1 {
-		//It is the other player's turn
-		return !initialPlayer
-	}
-	return initialPlayer
-}
-
 type BoardPos struct {
 	X int
 	Y int
@@ -188,6 +232,9 @@ func (p *BoardPos) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	if len(v) < 2 {
+		return fmt.Errorf("invalid board position: %s", data)
+	}
 	p.X = int(v[0])
 	p.Y = int(v[1])
 	return nil
@@ -209,83 +256,177 @@ func (g GameListData) GameOver() bool {
 	return !g.BlackLost || !g.WhiteLost
 }
 
-//GetGamesList returns a number of ongoing games you are actively participating in.
-//Pagination is currently not implemented, so only the first few active games will be returned.
-func GetGamesList() *GameList {
+//GetGamesList returns all ongoing games you are actively participating in, fetching every page.
+//To page through games yourself or to find finished games, use Client.Games.
+func (c *Client) GetGamesList(ctx context.Context) (*GameList, error) {
 	var gamelist GameList
-	var values url.Values = make(url.Values)
-	values.Set("ended__isnull", "true")
-	doGet(apiURL, "me/games", values, &gamelist)
-	return &gamelist
+	pager := c.Games(GameQuery{Status: ActiveGames})
+	for pager.HasNext() {
+		games, err := pager.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		gamelist.Games = append(gamelist.Games, games...)
+	}
+	gamelist.Count = len(gamelist.Games)
+	return &gamelist, nil
 }
 
-//GetGameData returns metadata for the given game ID, if it is public. If it is not, BoardData will be uninitialized.
+//GetGameData returns the game record (initial state, moves, rules etc.) from the termination API for the given game ID,
+//if it is public. If it is not, an OGSApiError is returned.
 //For getting the actual contents of the board, consider using GetGameState.
-func GetGameData(gameID int64) *BoardData {
-	var board BoardData
-	doGet(termApiURL, fmt.Sprintf("game/%d", gameID), nil, &board)
-	return &board
+//For player and result information, use GetGameDetails.
+func (c *Client) GetGameData(ctx context.Context, gameID int64) (*GameData, error) {
+	var gamedata GameData
+	if err := c.doGet(ctx, c.termApiURL(), fmt.Sprintf("game/%d", gameID), nil, &gamedata); err != nil {
+		return nil, err
+	}
+	return &gamedata, nil
+}
+
+//GetGameDetails returns the complete game information from api/v1/games/<id>: players, rules, time control,
+//start/end times, outcome and the full game record.
+func (c *Client) GetGameDetails(ctx context.Context, gameID int64) (*GameDetails, error) {
+	var details GameDetails
+	if err := c.doGet(ctx, c.apiURL(), fmt.Sprintf("games/%d", gameID), nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
 }
 
 //GetGameState returns the whole board's state and the last played move, among other things.
 //This endpoint contains little to no other metadata.
-func GetGameState(gameID int64) *BoardState {
+func (c *Client) GetGameState(ctx context.Context, gameID int64) (*BoardState, error) {
 	var board BoardState
-	doGet(termApiURL, fmt.Sprintf("game/%d/state", gameID), nil, &board)
-	return &board
+	if err := c.doGet(ctx, c.termApiURL(), fmt.Sprintf("game/%d/state", gameID), nil, &board); err != nil {
+		return nil, err
+	}
+	return &board, nil
 }
 
 //AuthenticateRefreshToken authenticates with a token from the user.
-//Either this function or
-func AuthenticateRefreshToken(refreshToken string) error {
+//Either this function or AuthenticatePassword must be called before using authenticated endpoints.
+func (c *Client) AuthenticateRefreshToken(ctx context.Context, refreshToken string) error {
 	var oauthResponse OauthResponse
 	var apiError *OGSApiError
 	var values url.Values = make(url.Values)
-	values.Set("client_id", OauthClientID)
+	values.Set("client_id", c.ClientID)
 	values.Set("grant_type", "refresh_token")
 	values.Set("refresh_token", refreshToken)
-	err := doPostForm(oauthURL, "token/", values, &oauthResponse) //trailing slash to path is required!
+	err := c.doPostForm(ctx, c.oauthURL(), "token/", values, &oauthResponse) //trailing slash to path is required!
 
 	if errors.As(err, &apiError) && oauthResponse.Error != "" {
 		return InvalidRefreshToken
 	} else if err != nil {
 		return err
 	}
-	AuthData.Oauth = oauthResponse
-	getPlayerForAuth()
-	return nil
+	c.authMu.Lock()
+	c.setOauth(oauthResponse)
+	c.authMu.Unlock()
+	return c.getPlayerForAuth(ctx)
 }
 
-func AuthenticatePassword(username, password string) error {
+//AuthenticatePassword authenticates with the user's OGS username and password.
+func (c *Client) AuthenticatePassword(ctx context.Context, username, password string) error {
 	var oauthResponse OauthResponse
 	if username == "" || password == "" {
 		return errors.New("Username/password required")
 	}
 	var values url.Values = make(url.Values)
-	values.Set("client_id", OauthClientID)
+	values.Set("client_id", c.ClientID)
 	values.Set("grant_type", "password")
 	values.Set("username", username)
 	values.Set("password", password)
-	err := doPostForm(oauthURL, "token/", values, &oauthResponse) //trailing slash to path is required!
+	err := c.doPostForm(ctx, c.oauthURL(), "token/", values, &oauthResponse) //trailing slash to path is required!
 	if oauthResponse.Error != "" {
-		return errors.New(oauthResponse.GetError()) //may panic, depending on error
+		return oauthResponse.GetError()
 	} else if err != nil {
 		return err
 	}
-	AuthData.Oauth = oauthResponse
-	getPlayerForAuth()
-	return nil
+	c.authMu.Lock()
+	c.setOauth(oauthResponse)
+	c.authMu.Unlock()
+	return c.getPlayerForAuth(ctx)
+}
+
+//setOauth stores newly obtained tokens along with their expiry time. authMu must be held.
+func (c *Client) setOauth(o OauthResponse) {
+	c.AuthData.Oauth = o
+	c.AuthData.Expiry = time.Now().Add(time.Duration(o.ExpiresIn) * time.Second)
+}
+
+//refreshAccessToken exchanges the stored refresh token for a new access token.
+//staleToken is the access token the caller found to be expired; if another call already replaced it,
+//nothing is done. OnTokenRefresh is called after a successful refresh.
+func (c *Client) refreshAccessToken(ctx context.Context, staleToken string) error {
+	c.authMu.Lock()
+	if c.AuthData.Oauth.AccessToken != staleToken {
+		c.authMu.Unlock()
+		return nil
+	}
+	var oauthResponse OauthResponse
+	var apiError *OGSApiError
+	var values url.Values = make(url.Values)
+	values.Set("client_id", c.ClientID)
+	values.Set("grant_type", "refresh_token")
+	values.Set("refresh_token", c.AuthData.Oauth.RefreshToken)
+	err := c.doRequest(ctx, "POST", c.oauthURL(), "token/", "", "", values, &oauthResponse)
+	if errors.As(err, &apiError) && oauthResponse.Error != "" {
+		err = InvalidRefreshToken
+	}
+	if err == nil {
+		c.setOauth(oauthResponse)
+	}
+	c.authMu.Unlock()
+
+	if err == nil && c.OnTokenRefresh != nil {
+		c.OnTokenRefresh(oauthResponse)
+	}
+	return err
+}
+
+//currentToken returns the access token to use for a request, refreshing it first if it is about to expire.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	c.authMu.Lock()
+	token, refresh, expiry := c.AuthData.Oauth.AccessToken, c.AuthData.Oauth.RefreshToken, c.AuthData.Expiry
+	c.authMu.Unlock()
+	if token == "" || refresh == "" || expiry.IsZero() || time.Until(expiry) > tokenRefreshMargin {
+		return token, nil
+	}
+	if err := c.refreshAccessToken(ctx, token); err != nil {
+		return "", err
+	}
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.AuthData.Oauth.AccessToken, nil
 }
 
-func getPlayerForAuth() {
+//canRefresh returns true if there is a refresh token to get a new access token with.
+func (c *Client) canRefresh() bool {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.AuthData.Oauth.RefreshToken != ""
+}
+
+func (c *Client) getPlayerForAuth(ctx context.Context) error {
 	var me Player
-	err := doGet(apiURL, "me", nil, &me)
+	err := c.doGet(ctx, c.apiURL(), "me", nil, &me)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	//if the call succeeds, user must be authenticated
-	AuthData.Player = me
-	AuthData.Authenticated = true
+	c.authMu.Lock()
+	c.AuthData.Player = me
+	c.AuthData.Authenticated = true
+	c.authMu.Unlock()
+	return nil
+}
+
+//Auth returns a copy of AuthData that is safe to use while tokens are being refreshed.
+func (c *Client) Auth() UserInfo {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.AuthData
 }
 
 type OGSConfig struct {
@@ -294,25 +435,74 @@ type OGSConfig struct {
 
 //GetOGSConfig gets the ui/config endpoint from OGS.
 //Only one parameter, chat_auth, is extracted for use with the realtime API.
-func GetOGSConfig() *OGSConfig {
+func (c *Client) GetOGSConfig(ctx context.Context) (*OGSConfig, error) {
 	o := &OGSConfig{}
-	doGet(apiURL, "ui/config", nil, o)
-	return o
+	if err := c.doGet(ctx, c.apiURL(), "ui/config", nil, o); err != nil {
+		return nil, err
+	}
+	return o, nil
 }
 
-func doPostForm(apiURL, apiPath string, values url.Values, unpack any) error {
-	return handleRequest("POST", apiURL, apiPath, "", values, &unpack)
+//getNext follows a "next" link of a paginated API response. Only the path and query of the link are used,
+//on the current apiURL, so the access token is never sent to whatever host the link names.
+func (c *Client) getNext(ctx context.Context, next string, unpack any) error {
+	u, err := url.Parse(next)
+	if err == nil && !strings.HasPrefix(u.Path, "/api/v1/") {
+		err = errors.New("not an API link")
+	}
+	if err != nil {
+		return &DecodeError{Path: next, Err: fmt.Errorf("invalid next page link: %w", err)}
+	}
+	apiPath := strings.TrimPrefix(u.Path, "/api/v1/")
+	if u.RawQuery != "" {
+		apiPath += "?" + u.RawQuery
+	}
+	return c.doGet(ctx, c.apiURL(), apiPath, nil, unpack)
 }
 
-func doPostJSON(apiURL, apiPath string, jsonstr string, unpack any) error {
-	return handleRequest("POST", apiURL, apiPath, jsonstr, nil, &unpack)
+func (c *Client) doDelete(ctx context.Context, apiURL, apiPath string, unpack any) error {
+	return c.handleRequest(ctx, "DELETE", apiURL, apiPath, "", nil, &unpack)
 }
 
-func doGet(apiURL, apiPath string, values url.Values, unpack any) error {
-	return handleRequest("GET", apiURL, apiPath, "", values, &unpack)
+func (c *Client) doPostForm(ctx context.Context, apiURL, apiPath string, values url.Values, unpack any) error {
+	return c.handleRequest(ctx, "POST", apiURL, apiPath, "", values, &unpack)
 }
 
-func handleRequest(httpMethod string, apiURL, apiPath, jsonstr string, postValues url.Values, unpack *any) error {
+func (c *Client) doPostJSON(ctx context.Context, apiURL, apiPath string, jsonstr string, unpack any) error {
+	return c.handleRequest(ctx, "POST", apiURL, apiPath, jsonstr, nil, &unpack)
+}
+
+func (c *Client) doGet(ctx context.Context, apiURL, apiPath string, values url.Values, unpack any) error {
+	return c.handleRequest(ctx, "GET", apiURL, apiPath, "", values, &unpack)
+}
+
+//handleRequest performs an API call with the current access token. If the token is about to expire,
+//it is refreshed first; if OGS rejects it anyway, it is refreshed and the request is retried once.
+//The request is aborted when ctx is cancelled or its deadline passes.
+func (c *Client) handleRequest(ctx context.Context, httpMethod string, apiURL, apiPath, jsonstr string, postValues url.Values, unpack *any) error {
+	if apiURL == c.oauthURL() {
+		//token requests are authenticated by their parameters
+		return c.doRequest(ctx, httpMethod, apiURL, apiPath, jsonstr, "", postValues, *unpack)
+	}
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.doRequest(ctx, httpMethod, apiURL, apiPath, jsonstr, token, postValues, *unpack)
+	if errors.Is(err, ErrUnauthorized) && token != "" && c.canRefresh() {
+		if refreshErr := c.refreshAccessToken(ctx, token); refreshErr != nil {
+			return err
+		}
+		token, _ = c.currentToken(ctx)
+		err = c.doRequest(ctx, httpMethod, apiURL, apiPath, jsonstr, token, postValues, *unpack)
+	}
+	return err
+}
+
+//doRequest performs a single HTTP request, using token as bearer token if it is not empty.
+//Errors are returned as NetworkError, OGSApiError or DecodeError. For error responses, the body is
+//still unpacked when possible, since the oauth endpoint returns its error details that way.
+func (c *Client) doRequest(ctx context.Context, httpMethod string, apiURL, apiPath, jsonstr, token string, postValues url.Values, unpack any) error {
 	var r io.Reader
 	if jsonstr != "" {
 		r = strings.NewReader(jsonstr)
@@ -320,7 +510,7 @@ func handleRequest(httpMethod string, apiURL, apiPath, jsonstr string, postValue
 	if httpMethod != "GET" && postValues != nil {
 		r = strings.NewReader(postValues.Encode())
 	}
-	req, err := http.NewRequest(httpMethod, fmt.Sprintf("%s%s", apiURL, apiPath), r)
+	req, err := http.NewRequestWithContext(ctx, httpMethod, fmt.Sprintf("%s%s", apiURL, apiPath), r)
 	if err != nil {
 		return err
 	}
@@ -332,70 +522,68 @@ func handleRequest(httpMethod string, apiURL, apiPath, jsonstr string, postValue
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		}
 	}
-	if AuthData.Oauth.AccessToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", AuthData.Oauth.AccessToken))
+	if jsonstr != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
-	resp, err := client.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return err
+		return &NetworkError{Path: apiPath, Err: err}
 	}
 	defer resp.Body.Close()
 
 	respData, err := ioutil.ReadAll(resp.Body)
-	json.Unmarshal(respData, &unpack)
-	if resp.StatusCode != 200 {
-		return &OGSApiError{Code: resp.StatusCode, err: errors.New(fmt.Sprintf("Error calling /%s: %s", apiPath, resp.Status))}
-	}
 	if err != nil {
-		return err
+		return &NetworkError{Path: apiPath, Err: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		json.Unmarshal(respData, unpack)
+		return newOGSApiError(resp.StatusCode, resp.Status, apiPath, respData)
+	}
+	if len(respData) > 0 {
+		if err = json.Unmarshal(respData, unpack); err != nil {
+			return &DecodeError{Path: apiPath, Err: err}
+		}
 	}
 	return nil
 }
 
-//ConvertSGCoords turns an SGF coordinates string (2 letters for col+row) to a list of board positions.
+//ConvertSGFCoords turns an SGF coordinates string (2 letters for col+row) to a list of board positions.
 //This doesn't contain any other context, like which player's turn it is.
-//This function is currently not used for anything, but is left here as a reference.
-func ConvertSGFCoords(sgf string) *[]BoardPos {
-	if len(sgf)%2 == 1 {
-		panic(fmt.Sprintf("invalid length for sgf coordinate string: %s", sgf))
+//It is used for lists of stones, like the initial state and removed stones of a game, which OGS sends
+//as a single string instead of an SGF list of values.
+func ConvertSGFCoords(coords string) ([]BoardPos, error) {
+	if len(coords)%2 == 1 {
+		return nil, fmt.Errorf("invalid length for sgf coordinate string: %s", coords)
 	}
-
-	var posList []BoardPos = make([]BoardPos, len(sgf)/2)
+	posList := make([]BoardPos, len(coords)/2)
 	for i := range posList {
-		posList[i] = BoardPos{
-			X: SGFInt(sgf[i*2]),
-			Y: SGFInt(sgf[(i*2)+1]),
+		p, err := sgf.ParsePoint(coords[i*2 : i*2+2])
+		if err != nil {
+			return nil, err
 		}
+		posList[i] = BoardPos{X: p.X, Y: p.Y}
 	}
-	return &posList
+	return posList, nil
 }
 
-//SGFInt converts a sgf notation letter to integer, which is required for
-//reading the initial state parameter of the legacy single game endpoint.
-//This function is currently not used for anything, but is left here as a reference.
-func SGFInt(r byte) int {
-	rInt := int(r)
-	switch {
-	case rInt >= rAL && rInt <= rZL:
-		//lowercase a corresponds to 0
-		return rInt - rAL
-	case rInt >= rAU && rInt <= rZU:
-		//uppercase A comes after lowercase z
-		return rInt - rAU + 26
-	default:
-		panic(fmt.Sprintf("invalid sgf coordinate rune: %c", r))
+//PosListSGF converts a list of positions to a single string of SGF coordinates, as used by OGS for lists of stones.
+func PosListSGF(l []BoardPos) string {
+	var sb strings.Builder
+	for _, p := range l {
+		sb.WriteString(PosSGF(p))
 	}
+	return sb.String()
 }
 
 //PosSGF converts a BoardPos x, y struct to SGF coordinate notation, which is required
-//for posting moves to the realtime API.
-//The SGF notation has two letters per coordinate, where "aa" is the upper left corner,
-//"ba" is one stone to the right of that, "bc" is two stones below "ba", etc.
+//for posting moves to the realtime API. See sgf.EncodePoint for the notation.
 func PosSGF(p BoardPos) string {
 	if p.X == -1 && p.Y == -1 {
 		//Not official, but used by OGS
 		return ".."
 	}
-	//Uppercase letters aren't implemented at the moment, so it's only up to 26x26 boards
-	return fmt.Sprintf("%c%c", rune(rAL+p.X), rune(rAL+p.Y))
+	return sgf.EncodePoint(p.X, p.Y)
 }
@@ -1,20 +1,61 @@
 package api
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
 
 	gosocketio "github.com/graarh/golang-socketio"
 	"github.com/graarh/golang-socketio/transport"
 )
 
-//A socket client wrapper for communicating with the realtime API
+//A socket client wrapper for communicating with the realtime API. A single RealtimeClient can join any number of games;
+//use Client.Realtime to get the connection that is shared by everything acting as the same user.
+//If the connection drops, the RealtimeClient reconnects by itself; see RealtimeGame.OnConnectionState.
 type RealtimeClient struct {
-	c      *gosocketio.Client
-	GameID int64
+	client        *Client
+	mu            sync.Mutex //guards everything below, which changes when reconnecting
+	c             *gosocketio.Client
+	subs          map[string]map[int]func(json.RawMessage) //event handlers by event name and subscription ID
+	stateSubs     map[int]func(ConnectionState, error)     //connection state handlers by subscription ID
+	nextSub       int
+	games         map[int64]*joinedGame               //games that are joined again after reconnecting
+	resubscribe   map[int]func(context.Context) error //other subscriptions that are set up again after reconnecting
+	authenticated bool                                //whether Authenticate was called, so it must be repeated after reconnecting
+	lost          chan *gosocketio.Client             //connections that were closed, see keepAlive
+	closed        chan struct{}
 }
 
-var (
-	wsUrl = "wss://online-go.com/socket.io/?EIO=3&transport=websocket"
+//joinedGame counts the RealtimeGames subscribed to a game.
+type joinedGame struct {
+	refs     int
+	chatRefs int //how many of them want chat messages
+}
+
+//ConnectionState describes whether a RealtimeClient is connected to the realtime server.
+type ConnectionState int
+
+const (
+	//Connected means the socket is open. It is reported after a successful reconnect.
+	Connected ConnectionState = iota
+	//Reconnecting means the socket was lost and a new connection is being set up.
+	//Emits fail with ErrOffline in this state, and no events are received.
+	Reconnecting
+)
+
+func (s ConnectionState) String() string {
+	if s == Connected {
+		return "connected"
+	}
+	return "reconnecting"
+}
+
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = time.Minute
+	reconnectTimeout  = 30 * time.Second //time allowed for dialing and authenticating on each attempt
+	ackTimeout        = 30 * time.Second //time to wait for an answer to a request, if the context has no deadline
 )
 
 type EmitAuth struct {
@@ -29,89 +70,486 @@ type EmitGameConnect struct {
 	Chat     bool  `json:"chat"`
 }
 
+//EmitGameDisconnect stops the events of a game from being sent.
+type EmitGameDisconnect struct {
+	GameID int64 `json:"game_id"`
+}
+
 type EmitMove struct {
 	GameID   int64  `json:"game_id"`
 	PlayerID int64  `json:"player_id"`
 	Move     string `json:"move"`
 }
 
-//Create a new RealtimeClient which opens a socket connection for a specific game ID.
-//The RealtimeClient is currently made to connect to one game at a time.
-//An optional function f may be provided that will get called whenever the "game/<id>/gamedata"
-//event is received, which happens directly after connecting and during the stone removal/finished phases.
-//You are responsible for calling Disconnect() when the RealtimeClient is no longer required.
-func Connect(gameID int64, f func(map[string]interface{})) (*RealtimeClient, error) {
-	var r *RealtimeClient = &RealtimeClient{GameID: gameID}
-	c, err := gosocketio.Dial(wsUrl, transport.GetDefaultWebsocketTransport())
+//EmitRemovedStones marks stones as dead (Removed = true) or alive during the stone removal phase.
+type EmitRemovedStones struct {
+	GameID   int64  `json:"game_id"`
+	PlayerID int64  `json:"player_id"`
+	Removed  bool   `json:"removed"`
+	Stones   string `json:"stones"` //SGF coordinates
+}
+
+//EmitAcceptRemovedStones accepts the stones currently marked as dead, which ends the game once both players accept.
+type EmitAcceptRemovedStones struct {
+	GameID         int64  `json:"game_id"`
+	PlayerID       int64  `json:"player_id"`
+	Stones         string `json:"stones"` //SGF coordinates of all stones marked as dead
+	StrictSekiMode bool   `json:"strict_seki_mode"`
+}
+
+//EmitUndo is used for undo requests and answers. MoveNumber is the number of moves played when the undo was requested.
+type EmitUndo struct {
+	GameID     int64 `json:"game_id"`
+	PlayerID   int64 `json:"player_id"`
+	MoveNumber int   `json:"move_number"`
+}
+
+//EmitChat sends a chat message to one of the chat channels of a game.
+type EmitChat struct {
+	GameID     int64       `json:"game_id"`
+	PlayerID   int64       `json:"player_id"`
+	Username   string      `json:"username"`
+	Body       string      `json:"body"`
+	Type       ChatChannel `json:"type"`
+	MoveNumber int         `json:"move_number"`
+}
+
+//EmitGameAction is used for game events that only need to identify the game and player, like resigning.
+type EmitGameAction struct {
+	GameID   int64 `json:"game_id"`
+	PlayerID int64 `json:"player_id"`
+}
+
+//Realtime returns the connection to the realtime API that is shared by everything using the Client,
+//opening it first if there is none yet. If the Client is authenticated at that point, so is the connection.
+//The socket connects to the realtime server belonging to the Client's BaseURL.
+//Dialing is aborted when ctx is done; ctx is not used after Realtime returns.
+//The connection stays open until Disconnect is called, after which the next call opens a new one.
+func (client *Client) Realtime(ctx context.Context) (*RealtimeClient, error) {
+	client.realtimeMu.Lock()
+	defer client.realtimeMu.Unlock()
+	if client.realtime != nil {
+		return client.realtime, nil
+	}
+	r := &RealtimeClient{
+		client:      client,
+		subs:        make(map[string]map[int]func(json.RawMessage)),
+		stateSubs:   make(map[int]func(ConnectionState, error)),
+		games:       make(map[int64]*joinedGame),
+		resubscribe: make(map[int]func(context.Context) error),
+		lost:        make(chan *gosocketio.Client),
+		closed:      make(chan struct{}),
+	}
+	c, err := dial(ctx, client.realtimeURL())
 	if err != nil {
 		return nil, err
 	}
-	if f != nil {
-		aFunc := func(i interface{}, response map[string]interface{}) {
-			f(response)
+	r.attach(c)
+	if client.AuthData.Authenticated {
+		if err = r.Authenticate(ctx); err != nil {
+			close(r.closed)
+			c.Close()
+			return nil, err
 		}
-		c.On(fmt.Sprintf("game/%d/gamedata", gameID), aFunc)
 	}
-	c.Emit("game/connect", &EmitGameConnect{
-		GameID:   r.GameID,
-		PlayerID: AuthData.Player.ID,
-		Chat:     false,
-	})
-	r.c = c
+	go r.keepAlive()
+	client.realtime = r
 	return r, nil
 }
 
-//OnMoveResult is used as a return for the OnMove callback event.
-type OnMoveResult struct {
-	GameID     int64    `json:"game_id"`
-	Move       BoardPos `json:"move"`
-	MoveNumber int      `json:"move_number"`
+//Join subscribes to the events of a game. Any number of RealtimeGames can be joined for the same game, each
+//with their own event handlers; OGS is asked to stop sending events once all of them have called Leave.
+//If chat is true, chat messages are sent as well, starting with the chat history of the game; use OnChat to receive them.
+//An optional function f may be provided that will get called whenever the "game/<id>/gamedata"
+//event is received, which happens directly after joining (and reconnecting) and during the stone removal/finished phases.
+//Note that joining a game that was already joined makes OGS resend the gamedata event to all of its RealtimeGames.
+//Fields that are missing from the event are left at their zero values.
+func (r *RealtimeClient) Join(ctx context.Context, gameID int64, chat bool, f func(*GameData)) (*RealtimeGame, error) {
+	g := &RealtimeGame{GameID: gameID, r: r, chat: chat}
+	if f != nil {
+		g.on("gamedata", func(data json.RawMessage) {
+			var response GameData
+			if json.Unmarshal(data, &response) == nil {
+				f(&response)
+			}
+		})
+	}
+	r.mu.Lock()
+	j := r.games[gameID]
+	if j == nil {
+		j = &joinedGame{}
+		r.games[gameID] = j
+	}
+	j.refs++
+	if chat {
+		j.chatRefs++
+	}
+	wantChat := j.chatRefs > 0
+	r.mu.Unlock()
+	if err := r.connectGame(ctx, gameID, wantChat); err != nil {
+		g.Leave()
+		return nil, err
+	}
+	return g, nil
 }
 
-//OnMove registers a callback for whenever a move is played in the connected game.
-func (r *RealtimeClient) OnMove(f func(OnMoveResult)) {
-	aFunc := func(i interface{}, response OnMoveResult) {
-		f(response)
+//leave undoes a Join of gameID, and disconnects from the game if nothing else joined it.
+func (r *RealtimeClient) leave(gameID int64, chat bool) {
+	r.mu.Lock()
+	j := r.games[gameID]
+	if j == nil {
+		r.mu.Unlock()
+		return
+	}
+	j.refs--
+	if chat {
+		j.chatRefs--
+	}
+	last := j.refs == 0
+	if last {
+		delete(r.games, gameID)
+	}
+	r.mu.Unlock()
+	if last {
+		//a failure only means the socket is gone, which disconnects from the game just as well
+		r.emit(context.Background(), "game/disconnect", &EmitGameDisconnect{GameID: gameID})
 	}
-	r.c.On(fmt.Sprintf("game/%d/move", r.GameID), aFunc)
 }
 
-type OnClockResult struct {
-	CurrentPlayerID int64 `json:"current_player"`
-	BlackPlayerID   int64 `json:"black_player_id"`
-	WhitePlayerID   int64 `json:"white_player_id"`
-	LastMove        int64 `json:"last_move"` //ms since epoch
-	Now             int64 `json:"now"`       //ms since epoch
+//connectGame subscribes to the events of a game.
+func (r *RealtimeClient) connectGame(ctx context.Context, gameID int64, chat bool) error {
+	return r.emit(ctx, "game/connect", &EmitGameConnect{
+		GameID:   gameID,
+		PlayerID: r.client.AuthData.Player.ID,
+		Chat:     chat,
+	})
 }
 
-func (r *RealtimeClient) OnClock(f func(OnClockResult)) {
-	aFunc := func(i interface{}, response OnClockResult) {
-		f(response)
+//attach makes c the current connection and registers the event dispatchers on it.
+//It returns false if Disconnect was called in the meantime, in which case c is left alone.
+func (r *RealtimeClient) attach(c *gosocketio.Client) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.closed:
+		return false
+	default:
 	}
-	r.c.On(fmt.Sprintf("game/%d/clock", r.GameID), aFunc)
+	r.c = c
+	for event := range r.subs {
+		r.register(c, event)
+	}
+	c.On(gosocketio.OnDisconnection, func(i interface{}) {
+		//called while the socket holds its own lock, so closing it from here would deadlock
+		go r.disconnected(c)
+	})
+	return true
 }
 
-//Authenticate gets a token from the REST API which is submitted through the Realtime API websocket.
-//This is required before calling authenticated functions, like RealtimeClient.Move.
-//This function requires being authenticated through api.Authenticate first.
-func (r *RealtimeClient) Authenticate() {
-	auth := GetOGSConfig().ChatAuth
-	r.c.Emit("authenticate", &EmitAuth{
-		Auth:     auth,
-		Username: AuthData.Player.Username,
-		PlayerID: AuthData.Player.ID,
+//register makes c pass event to its subscribers.
+//The socket only keeps one handler per event, so this single handler hands the event to all subscribers.
+func (r *RealtimeClient) register(c *gosocketio.Client, event string) {
+	c.On(event, func(i interface{}, data json.RawMessage) {
+		r.dispatch(event, data)
 	})
 }
 
-func (r *RealtimeClient) Move(x, y int) {
-	r.c.Emit("game/move", &EmitMove{
-		GameID:   r.GameID,
-		PlayerID: AuthData.Player.ID,
-		Move:     PosSGF(BoardPos{X: x, Y: y}),
+func (r *RealtimeClient) dispatch(event string, data json.RawMessage) {
+	r.mu.Lock()
+	handlers := make([]func(json.RawMessage), 0, len(r.subs[event]))
+	for _, f := range r.subs[event] {
+		handlers = append(handlers, f)
+	}
+	r.mu.Unlock()
+	for _, f := range handlers {
+		f(data)
+	}
+}
+
+//subscribe adds an event handler and returns its subscription ID, which is used to unsubscribe.
+func (r *RealtimeClient) subscribe(event string, f func(json.RawMessage)) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSub++
+	if r.subs[event] == nil {
+		r.subs[event] = make(map[int]func(json.RawMessage))
+		if r.c != nil {
+			r.register(r.c, event)
+		}
+	}
+	r.subs[event][r.nextSub] = f
+	return r.nextSub
+}
+
+//subscribeState adds a connection state handler and returns its subscription ID.
+func (r *RealtimeClient) subscribeState(f func(ConnectionState, error)) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSub++
+	r.stateSubs[r.nextSub] = f
+	return r.nextSub
+}
+
+//subscribeReconnect adds a function that is called after reconnecting, to set up a subscription
+//on the server again. It returns a subscription ID.
+func (r *RealtimeClient) subscribeReconnect(f func(context.Context) error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSub++
+	r.resubscribe[r.nextSub] = f
+	return r.nextSub
+}
+
+//unsubscribe removes the handlers with the given subscription IDs.
+func (r *RealtimeClient) unsubscribe(ids []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		delete(r.stateSubs, id)
+		delete(r.resubscribe, id)
+		for _, handlers := range r.subs {
+			delete(handlers, id)
+		}
+	}
+}
+
+func (r *RealtimeClient) setState(state ConnectionState, err error) {
+	r.mu.Lock()
+	handlers := make([]func(ConnectionState, error), 0, len(r.stateSubs))
+	for _, f := range r.stateSubs {
+		handlers = append(handlers, f)
+	}
+	r.mu.Unlock()
+	for _, f := range handlers {
+		f(state, err)
+	}
+}
+
+//disconnected reports that c was closed to the goroutine running keepAlive.
+func (r *RealtimeClient) disconnected(c *gosocketio.Client) {
+	select {
+	case r.lost <- c:
+	case <-r.closed:
+	}
+}
+
+//keepAlive reconnects whenever the current connection is lost, until Disconnect is called.
+//Running this in a single goroutine makes sure there is only ever one reconnect going on.
+func (r *RealtimeClient) keepAlive() {
+	for {
+		select {
+		case <-r.closed:
+			return
+		case c := <-r.lost:
+			r.mu.Lock()
+			current := r.c == c
+			r.mu.Unlock()
+			//sockets that were replaced or given up on by reconnect are ignored
+			if current {
+				r.reconnectLoop()
+			}
+		}
+	}
+}
+
+//reconnectLoop tries to reconnect with exponential backoff until it succeeds or Disconnect is called.
+func (r *RealtimeClient) reconnectLoop() {
+	r.setState(Reconnecting, ErrOffline)
+	delay := reconnectMinDelay
+	for {
+		select {
+		case <-r.closed:
+			return
+		case <-time.After(delay):
+		}
+		err := r.reconnect()
+		if err == nil {
+			r.setState(Connected, nil)
+			return
+		}
+		r.setState(Reconnecting, err)
+		if delay *= 2; delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+//reconnect opens a new connection, then authenticates and joins all games again.
+func (r *RealtimeClient) reconnect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	c, err := dial(ctx, r.client.realtimeURL())
+	if err != nil {
+		return err
+	}
+	if !r.attach(c) {
+		c.Close()
+		return nil
+	}
+	r.mu.Lock()
+	authenticated := r.authenticated
+	r.mu.Unlock()
+	if authenticated {
+		err = r.Authenticate(ctx)
+	}
+	r.mu.Lock()
+	games := make(map[int64]bool, len(r.games))
+	for id, j := range r.games {
+		games[id] = j.chatRefs > 0
+	}
+	resubscribe := make([]func(context.Context) error, 0, len(r.resubscribe))
+	for _, f := range r.resubscribe {
+		resubscribe = append(resubscribe, f)
+	}
+	r.mu.Unlock()
+	for id, chat := range games {
+		if err != nil {
+			break
+		}
+		err = r.connectGame(ctx, id, chat)
+	}
+	for _, f := range resubscribe {
+		if err != nil {
+			break
+		}
+		err = f(ctx)
+	}
+	if err != nil {
+		//keepAlive ignores sockets that aren't current anymore
+		r.mu.Lock()
+		r.c = nil
+		r.mu.Unlock()
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+//dial opens a socket.io connection to url, giving up as soon as ctx is done.
+//The underlying dialer can't be interrupted, so a connection that completes after giving up is closed right away.
+func dial(ctx context.Context, url string) (*gosocketio.Client, error) {
+	type dialResult struct {
+		c   *gosocketio.Client
+		err error
+	}
+	ch := make(chan dialResult, 1)
+	go func() {
+		c, err := gosocketio.Dial(url, transport.GetDefaultWebsocketTransport())
+		ch <- dialResult{c, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.c, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-ch; res.c != nil {
+				res.c.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+//emit sends an event over the socket unless ctx is already done.
+//Emitting only queues the message, so it does not block on the network.
+//While the connection is down, ErrOffline is returned instead.
+func (r *RealtimeClient) emit(ctx context.Context, method string, args interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	c := r.c
+	r.mu.Unlock()
+	if c == nil || !c.IsAlive() {
+		return ErrOffline
+	}
+	return c.Emit(method, args)
+}
+
+//ack sends an event and waits for the server to answer it, decoding the answer into result.
+//The wait ends when ctx is done, or after ackTimeout if ctx has no deadline.
+func (r *RealtimeClient) ack(ctx context.Context, method string, args interface{}, result interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	c := r.c
+	r.mu.Unlock()
+	if c == nil || !c.IsAlive() {
+		return ErrOffline
+	}
+	timeout := ackTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	type ackResult struct {
+		data string
+		err  error
+	}
+	ch := make(chan ackResult, 1)
+	go func() {
+		data, err := c.Ack(method, args, timeout)
+		ch <- ackResult{data, err}
+	}()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return &NetworkError{Path: method, Err: res.err}
+		}
+		if err := json.Unmarshal([]byte(res.data), result); err != nil {
+			return &DecodeError{Path: method, Err: err}
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//Authenticate gets a token from the REST API which is submitted through the Realtime API websocket.
+//This is required before calling authenticated functions, like RealtimeGame.Move.
+//Client.Realtime does this by itself if the Client is authenticated when connecting.
+//This function requires the Client to be authenticated first.
+func (r *RealtimeClient) Authenticate(ctx context.Context) error {
+	ogsConfig, err := r.client.GetOGSConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = r.emit(ctx, "authenticate", &EmitAuth{
+		Auth:     ogsConfig.ChatAuth,
+		Username: r.client.AuthData.Player.Username,
+		PlayerID: r.client.AuthData.Player.ID,
 	})
+	if err == nil {
+		r.mu.Lock()
+		r.authenticated = true
+		r.mu.Unlock()
+	}
+	return err
 }
 
-//Disconnect closes the underlying websocket.
+//Disconnect closes the underlying websocket and stops reconnecting. All games are left.
 func (r *RealtimeClient) Disconnect() {
-	r.c.Close()
+	r.client.realtimeMu.Lock()
+	if r.client.realtime == r {
+		r.client.realtime = nil
+	}
+	r.client.realtimeMu.Unlock()
+	r.mu.Lock()
+	c := r.c
+	select {
+	case <-r.closed:
+	default:
+		close(r.closed)
+	}
+	r.mu.Unlock()
+	if c != nil {
+		c.Close()
+	}
 }
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+//RealtimeGame receives the events of a single game over a RealtimeClient, and sends moves and other actions for it.
+//It is created by RealtimeClient.Join. Event handlers only apply to this RealtimeGame, and are removed by Leave.
+type RealtimeGame struct {
+	GameID int64
+	r      *RealtimeClient
+	chat   bool
+	mu     sync.Mutex
+	subs   []int //subscription IDs of the handlers registered through this RealtimeGame
+	left   bool
+}
+
+//on registers a handler for the game event "game/<id>/<event>".
+func (g *RealtimeGame) on(event string, f func(json.RawMessage)) {
+	id := g.r.subscribe(fmt.Sprintf("game/%d/%s", g.GameID, event), f)
+	g.mu.Lock()
+	g.subs = append(g.subs, id)
+	g.mu.Unlock()
+}
+
+//OnConnectionState registers a callback for whenever the connection is lost or restored.
+//While reconnecting, the callback is called after every failed attempt with the error that caused it.
+//After reconnecting the game is joined again, which makes OGS resend the gamedata and chat events.
+func (g *RealtimeGame) OnConnectionState(f func(state ConnectionState, err error)) {
+	id := g.r.subscribeState(f)
+	g.mu.Lock()
+	g.subs = append(g.subs, id)
+	g.mu.Unlock()
+}
+
+//Leave removes the event handlers of this RealtimeGame. The connection itself stays open for other games.
+func (g *RealtimeGame) Leave() {
+	g.mu.Lock()
+	if g.left {
+		g.mu.Unlock()
+		return
+	}
+	g.left = true
+	subs := g.subs
+	g.mu.Unlock()
+	g.r.unsubscribe(subs)
+	g.r.leave(g.GameID, g.chat)
+}
+
+//OnMoveResult is used as a return for the OnMove callback event.
+type OnMoveResult struct {
+	GameID     int64    `json:"game_id"`
+	Move       BoardPos `json:"move"`
+	MoveNumber int      `json:"move_number"`
+}
+
+//OnMove registers a callback for whenever a move is played in the game.
+func (g *RealtimeGame) OnMove(f func(OnMoveResult)) {
+	g.on("move", func(data json.RawMessage) {
+		var response OnMoveResult
+		if json.Unmarshal(data, &response) == nil {
+			f(response)
+		}
+	})
+}
+
+//OnClockResult is used as a return for the OnClock callback event, and is also part of GameData.
+//BlackTime and WhiteTime are the times left at LastMove; use TimeLeft to get the times left right now.
+type OnClockResult struct {
+	GameID          int64       `json:"game_id"`
+	CurrentPlayerID int64       `json:"current_player"`
+	BlackPlayerID   int64       `json:"black_player_id"`
+	WhitePlayerID   int64       `json:"white_player_id"`
+	Title           string      `json:"title"`
+	LastMove        int64       `json:"last_move"`    //ms since epoch
+	Expiration      int64       `json:"expiration"`   //ms since epoch, when the current player runs out of time
+	Now             int64       `json:"now"`          //ms since epoch, server time when the event was sent
+	PausedSince     int64       `json:"paused_since"` //ms since epoch, 0 if the clock is running
+	StartMode       bool        `json:"start_mode"`   //true before the first moves, when a separate timer applies
+	BlackTime       PlayerClock `json:"black_time"`
+	WhiteTime       PlayerClock `json:"white_time"`
+}
+
+//OnClock registers a callback for clock updates, which are sent after every move and when the game is paused or resumed.
+func (g *RealtimeGame) OnClock(f func(OnClockResult)) {
+	g.on("clock", func(data json.RawMessage) {
+		var response OnClockResult
+		if json.Unmarshal(data, &response) == nil {
+			f(response)
+		}
+	})
+}
+
+//Move plays a move at x, y in the game. Use -1, -1 to pass.
+func (g *RealtimeGame) Move(ctx context.Context, x, y int) error {
+	return g.r.emit(ctx, "game/move", &EmitMove{
+		GameID:   g.GameID,
+		PlayerID: g.r.client.AuthData.Player.ID,
+		Move:     PosSGF(BoardPos{X: x, Y: y}),
+	})
+}
+
+//OnRemovedStonesResult is used as a return for the OnRemovedStones callback event.
+type OnRemovedStonesResult struct {
+	Removed    bool   `json:"removed"`     //whether Stones were marked dead or alive
+	Stones     string `json:"stones"`      //SGF coordinates of the stones that changed
+	AllRemoved string `json:"all_removed"` //SGF coordinates of all stones currently marked dead
+}
+
+//OnRemovedStones registers a callback for whenever stones are marked dead or alive during the stone removal phase.
+func (g *RealtimeGame) OnRemovedStones(f func(OnRemovedStonesResult)) {
+	g.on("removed_stones", func(data json.RawMessage) {
+		var response OnRemovedStonesResult
+		if json.Unmarshal(data, &response) == nil {
+			f(response)
+		}
+	})
+}
+
+//OnRemovedStonesAcceptedResult is used as a return for the OnRemovedStonesAccepted callback event.
+type OnRemovedStonesAcceptedResult struct {
+	PlayerID int64  `json:"player_id"`
+	Stones   string `json:"stones"`
+}
+
+//OnRemovedStonesAccepted registers a callback for whenever a player accepts the stones marked as dead.
+func (g *RealtimeGame) OnRemovedStonesAccepted(f func(OnRemovedStonesAcceptedResult)) {
+	g.on("removed_stones_accepted", func(data json.RawMessage) {
+		var response OnRemovedStonesAcceptedResult
+		if json.Unmarshal(data, &response) == nil {
+			f(response)
+		}
+	})
+}
+
+//SetRemovedStones marks the given stones as dead (removed = true) or alive during the stone removal phase.
+//OGS expects whole groups to be marked at once.
+func (g *RealtimeGame) SetRemovedStones(ctx context.Context, stones []BoardPos, removed bool) error {
+	return g.r.emit(ctx, "game/removed_stones/set", &EmitRemovedStones{
+		GameID:   g.GameID,
+		PlayerID: g.r.client.AuthData.Player.ID,
+		Removed:  removed,
+		Stones:   PosListSGF(stones),
+	})
+}
+
+//AcceptRemovedStones accepts the given stones as dead. This must be all stones currently marked as dead;
+//if the opponent changes them afterwards, the acceptance is void.
+func (g *RealtimeGame) AcceptRemovedStones(ctx context.Context, stones []BoardPos) error {
+	return g.r.emit(ctx, "game/removed_stones/accept", &EmitAcceptRemovedStones{
+		GameID:   g.GameID,
+		PlayerID: g.r.client.AuthData.Player.ID,
+		Stones:   PosListSGF(stones),
+	})
+}
+
+//RejectRemovedStones rejects the stones marked as dead, which resumes the game.
+func (g *RealtimeGame) RejectRemovedStones(ctx context.Context) error {
+	return g.r.emit(ctx, "game/removed_stones/reject", &EmitGameAction{
+		GameID:   g.GameID,
+		PlayerID: g.r.client.AuthData.Player.ID,
+	})
+}
+
+//OnUndoRequested registers a callback for whenever a player requests to undo the last move.
+//The callback receives the number of moves played when the undo was requested.
+func (g *RealtimeGame) OnUndoRequested(f func(moveNumber int)) {
+	g.on("undo_requested", func(data json.RawMessage) {
+		var moveNumber int
+		if json.Unmarshal(data, &moveNumber) == nil {
+			f(moveNumber)
+		}
+	})
+}
+
+//OnUndoAccepted registers a callback for whenever an undo request is accepted, after which the last move is taken back.
+//The callback receives the move number of the accepted request.
+func (g *RealtimeGame) OnUndoAccepted(f func(moveNumber int)) {
+	g.on("undo_accepted", func(data json.RawMessage) {
+		var moveNumber int
+		if json.Unmarshal(data, &moveNumber) == nil {
+			f(moveNumber)
+		}
+	})
+}
+
+//OnUndoCanceled registers a callback for whenever an undo request is withdrawn by the player who made it.
+func (g *RealtimeGame) OnUndoCanceled(f func(moveNumber int)) {
+	g.on("undo_canceled", func(data json.RawMessage) {
+		var moveNumber int
+		if json.Unmarshal(data, &moveNumber) == nil {
+			f(moveNumber)
+		}
+	})
+}
+
+//RequestUndo asks the opponent to take back the last move. moveNumber is the number of moves played so far.
+func (g *RealtimeGame) RequestUndo(ctx context.Context, moveNumber int) error {
+	return g.emitUndo(ctx, "game/undo/request", moveNumber)
+}
+
+//AcceptUndo accepts the opponent's undo request made at moveNumber.
+func (g *RealtimeGame) AcceptUndo(ctx context.Context, moveNumber int) error {
+	return g.emitUndo(ctx, "game/undo/accept", moveNumber)
+}
+
+//CancelUndo withdraws an undo request made at moveNumber.
+func (g *RealtimeGame) CancelUndo(ctx context.Context, moveNumber int) error {
+	return g.emitUndo(ctx, "game/undo/cancel", moveNumber)
+}
+
+func (g *RealtimeGame) emitUndo(ctx context.Context, method string, moveNumber int) error {
+	return g.r.emit(ctx, method, &EmitUndo{
+		GameID:     g.GameID,
+		PlayerID:   g.r.client.AuthData.Player.ID,
+		MoveNumber: moveNumber,
+	})
+}
+
+//OnChat registers a callback for chat messages in the game.
+//Messages from before connecting are sent again right after connecting, so a message may be received more than once;
+//use ChatLine.ChatID to tell them apart.
+func (g *RealtimeGame) OnChat(f func(OnChatResult)) {
+	g.on("chat", func(data json.RawMessage) {
+		var response OnChatResult
+		if json.Unmarshal(data, &response) == nil {
+			f(response)
+		}
+	})
+}
+
+//SendChat sends a chat message to the given channel of the game.
+//moveNumber is the number of moves played, which OGS shows alongside the message.
+func (g *RealtimeGame) SendChat(ctx context.Context, channel ChatChannel, moveNumber int, body string) error {
+	return g.r.emit(ctx, "game/chat", &EmitChat{
+		GameID:     g.GameID,
+		PlayerID:   g.r.client.AuthData.Player.ID,
+		Username:   g.r.client.AuthData.Player.Username,
+		Body:       body,
+		Type:       channel,
+		MoveNumber: moveNumber,
+	})
+}
+
+//Resign resigns the game. The result is announced through the gamedata event.
+func (g *RealtimeGame) Resign(ctx context.Context) error {
+	return g.r.emit(ctx, "game/resign", &EmitGameAction{
+		GameID:   g.GameID,
+		PlayerID: g.r.client.AuthData.Player.ID,
+	})
+}
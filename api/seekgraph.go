@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//SeekGraphEntry is an open challenge on the seek graph.
+type SeekGraphEntry struct {
+	ChallengeID     int64       `json:"challenge_id"`
+	GameID          int64       `json:"game_id"`
+	UserID          int64       `json:"user_id"`
+	Username        string      `json:"username"`
+	Ranking         float32     `json:"ranking"`
+	MinRank         float32     `json:"min_rank"` //lowest rank that may accept, on the same scale as Player.RawRanking
+	MaxRank         float32     `json:"max_rank"` //highest rank that may accept
+	Name            string      `json:"name"`
+	Rules           string      `json:"rules"`
+	Ranked          bool        `json:"ranked"`
+	Width           int         `json:"width"`
+	Height          int         `json:"height"`
+	Handicap        int         `json:"handicap"`
+	ChallengerColor string      `json:"challenger_color"`
+	TimePerMove     int         `json:"time_per_move"` //average seconds per move for the time control
+	TimeControl     TimeControl `json:"time_control_parameters"`
+}
+
+//Challenger returns the player who created the challenge.
+func (e SeekGraphEntry) Challenger() Player {
+	return Player{ID: e.UserID, Username: e.Username, RawRanking: e.Ranking}
+}
+
+func (e SeekGraphEntry) Description() string {
+	ranked := "unranked"
+	if e.Ranked {
+		ranked = "ranked"
+	}
+	return fmt.Sprintf("%s, %dx%d %s, %s, %s", e.Challenger(), e.Width, e.Height, e.Rules, ranked, e.TimeControl)
+}
+
+//seekGraphUpdate is an item of the seekgraph/global event, which either adds or removes a challenge.
+type seekGraphUpdate struct {
+	SeekGraphEntry
+	Delete int `json:"delete"` //1 if the challenge was removed
+}
+
+//SeekGraph keeps the list of open challenges up to date. Create one with RealtimeClient.SeekGraph.
+type SeekGraph struct {
+	r       *RealtimeClient
+	mu      sync.Mutex
+	entries map[int64]SeekGraphEntry
+	subs    []int
+	closed  bool
+}
+
+//SeekGraph subscribes to the seek graph. f is called with all open challenges, sorted by rank,
+//whenever the list changes. OGS sends the current list right after subscribing.
+//Call Close when the list is no longer needed.
+func (r *RealtimeClient) SeekGraph(ctx context.Context, f func([]SeekGraphEntry)) (*SeekGraph, error) {
+	s := &SeekGraph{r: r, entries: make(map[int64]SeekGraphEntry)}
+	s.subs = append(s.subs, r.subscribe("seekgraph/global", func(data json.RawMessage) {
+		var updates []seekGraphUpdate
+		if json.Unmarshal(data, &updates) != nil {
+			return
+		}
+		f(s.update(updates))
+	}))
+	s.subs = append(s.subs, r.subscribeReconnect(func(ctx context.Context) error {
+		//the full list is sent again after subscribing
+		s.mu.Lock()
+		s.entries = make(map[int64]SeekGraphEntry)
+		s.mu.Unlock()
+		return s.connect(ctx)
+	}))
+	if err := s.connect(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SeekGraph) connect(ctx context.Context) error {
+	return s.r.emit(ctx, "seek_graph/connect", map[string]string{"channel": "global"})
+}
+
+//update applies updates to the list of challenges and returns the new list.
+func (s *SeekGraph) update(updates []seekGraphUpdate) []SeekGraphEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range updates {
+		if u.Delete != 0 {
+			delete(s.entries, u.ChallengeID)
+		} else {
+			s.entries[u.ChallengeID] = u.SeekGraphEntry
+		}
+	}
+	entries := make([]SeekGraphEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Ranking != entries[j].Ranking {
+			return entries[i].Ranking > entries[j].Ranking
+		}
+		return entries[i].ChallengeID < entries[j].ChallengeID
+	})
+	return entries
+}
+
+//Close unsubscribes from the seek graph.
+func (s *SeekGraph) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+	s.r.unsubscribe(s.subs)
+	s.r.emit(context.Background(), "seek_graph/disconnect", map[string]string{"channel": "global"})
+}
+
+//AcceptOpenChallenge accepts an open challenge, e.g. from the seek graph, which starts its game.
+func (c *Client) AcceptOpenChallenge(ctx context.Context, challengeID int64) error {
+	var response interface{} //the response body is not needed
+	return c.doPostJSON(ctx, c.apiURL(), fmt.Sprintf("challenges/%d/accept", challengeID), "{}", &response)
+}
+
+//AutomatchRequest describes the games automatch may find. Zero values mean no preference.
+type AutomatchRequest struct {
+	Sizes []int  //board sizes, e.g. 9, 13 and 19; defaults to 19
+	Speed string //blitz, live or correspondence; defaults to live
+	Rules string //japanese, chinese, aga, korean, nz or ing
+}
+
+//EmitFindMatch starts looking for an opponent.
+type EmitFindMatch struct {
+	UUID             string                     `json:"uuid"`
+	SizeSpeedOptions []AutomatchSizeSpeed       `json:"size_speed_options"`
+	LowerRankDiff    int                        `json:"lower_rank_diff"`
+	UpperRankDiff    int                        `json:"upper_rank_diff"`
+	Rules            AutomatchCondition         `json:"rules"`
+	TimeControl      AutomatchCondition         `json:"time_control"`
+	Handicap         AutomatchCondition         `json:"handicap"`
+	Extra            map[string]json.RawMessage `json:"extra,omitempty"`
+}
+
+type AutomatchSizeSpeed struct {
+	Size  string `json:"size"` //e.g. "19x19"
+	Speed string `json:"speed"`
+}
+
+//AutomatchCondition is a preference for a game setting: "no-preference", "preferred" or "required".
+type AutomatchCondition struct {
+	Condition string      `json:"condition"`
+	Value     interface{} `json:"value"`
+}
+
+//Automatch is a pending automatch request. Create one with RealtimeClient.FindMatch.
+type Automatch struct {
+	UUID string
+	r    *RealtimeClient
+	subs []int
+	once sync.Once
+}
+
+//FindMatch asks OGS to find an opponent. f is called with the ID of the new game once one is found,
+//after which the Automatch is done. Call Cancel to stop looking before that.
+func (r *RealtimeClient) FindMatch(ctx context.Context, req AutomatchRequest, f func(gameID int64)) (*Automatch, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	args := &EmitFindMatch{
+		UUID:          uuid,
+		LowerRankDiff: 3,
+		UpperRankDiff: 3,
+		Rules:         AutomatchCondition{Condition: "no-preference", Value: "japanese"},
+		TimeControl:   AutomatchCondition{Condition: "no-preference", Value: map[string]string{"system": "byoyomi"}},
+		Handicap:      AutomatchCondition{Condition: "no-preference", Value: "enabled"},
+	}
+	speed := req.Speed
+	if speed == "" {
+		speed = "live"
+	}
+	sizes := req.Sizes
+	if len(sizes) == 0 {
+		sizes = []int{19}
+	}
+	for _, size := range sizes {
+		args.SizeSpeedOptions = append(args.SizeSpeedOptions, AutomatchSizeSpeed{Size: fmt.Sprintf("%dx%d", size, size), Speed: speed})
+	}
+	if req.Rules != "" {
+		args.Rules = AutomatchCondition{Condition: "required", Value: req.Rules}
+	}
+	a := &Automatch{UUID: uuid, r: r}
+	a.subs = append(a.subs, r.subscribe("automatch/start", func(data json.RawMessage) {
+		var start struct {
+			UUID   string `json:"uuid"`
+			GameID int64  `json:"game_id"`
+		}
+		if json.Unmarshal(data, &start) != nil || start.UUID != uuid {
+			return
+		}
+		started := false
+		a.once.Do(func() {
+			started = true
+			r.unsubscribe(a.subs)
+		})
+		if started {
+			f(start.GameID)
+		}
+	}))
+	a.subs = append(a.subs, r.subscribeReconnect(func(ctx context.Context) error {
+		return r.emit(ctx, "automatch/find_match", args)
+	}))
+	if err := r.emit(ctx, "automatch/find_match", args); err != nil {
+		a.Cancel()
+		return nil, err
+	}
+	return a, nil
+}
+
+//Cancel stops looking for an opponent. It does nothing if a game was already found.
+func (a *Automatch) Cancel() {
+	a.once.Do(func() {
+		a.r.unsubscribe(a.subs)
+		a.r.emit(context.Background(), "automatch/cancel", map[string]string{"uuid": a.UUID})
+	})
+}
+
+//newUUID returns a random (version 4) UUID, which OGS uses to identify automatch requests.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
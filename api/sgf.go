@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lvank/termsuji/sgf"
+)
+
+//sgfRules maps OGS rulesets to the values of the SGF RU property.
+var sgfRules = map[string]string{
+	"japanese": "Japanese",
+	"chinese":  "Chinese",
+	"aga":      "AGA",
+	"korean":   "Korean",
+	"nz":       "NZ",
+	"ing":      "GOE",
+}
+
+//SGF builds an SGF game tree of the game: the game information and handicap stones go in the root node,
+//followed by a node for each move of GameData. Chat messages are added as comments to the node of the move
+//they were sent at, in the given order.
+func (g *GameDetails) SGF(chat []OnChatResult) (*sgf.Node, error) {
+	root := &sgf.Node{}
+	root.Set("FF", "4")
+	root.Set("GM", "1")
+	root.Set("CA", "UTF-8")
+	root.Set("AP", "termsuji")
+	if g.Width == g.Height {
+		root.Set("SZ", strconv.Itoa(g.Width))
+	} else {
+		root.Set("SZ", fmt.Sprintf("%d:%d", g.Width, g.Height))
+	}
+	if g.Name != "" {
+		root.Set("GN", g.Name)
+	}
+	black, white := g.Players.Black, g.Players.White
+	if black.Username == "" {
+		black, white = g.GameData.Players.Black, g.GameData.Players.White
+	}
+	root.Set("PB", black.Username)
+	root.Set("BR", sgfRank(black))
+	root.Set("PW", white.Username)
+	root.Set("WR", sgfRank(white))
+	if ru, ok := sgfRules[g.Rules]; ok {
+		root.Set("RU", ru)
+	} else if g.Rules != "" {
+		root.Set("RU", g.Rules)
+	}
+	root.Set("KM", strconv.FormatFloat(g.Komi, 'f', -1, 64))
+	if g.Handicap > 0 {
+		root.Set("HA", strconv.Itoa(g.Handicap))
+	}
+	if !g.Started.IsZero() {
+		root.Set("DT", g.Started.Format("2006-01-02"))
+	}
+	if tm := g.TimeControl.mainTime(); tm > 0 {
+		root.Set("TM", strconv.Itoa(tm))
+	}
+	root.Set("OT", g.TimeControl.String())
+	if result := g.Result(); result != "" {
+		root.Set("RE", result)
+	}
+	for _, setup := range []struct{ id, stones string }{
+		{"AB", g.GameData.InitialState.Black},
+		{"AW", g.GameData.InitialState.White},
+	} {
+		points, err := ConvertSGFCoords(setup.stones)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			root.Add(setup.id, sgf.EncodePoint(p.X, p.Y))
+		}
+	}
+
+	nodes := []*sgf.Node{root} //node for each move number
+	for i, m := range g.GameData.Moves {
+		node := nodes[i].AddChild()
+		color := "W"
+		if g.GameData.ColorForMove(i) {
+			color = "B"
+		}
+		node.Set(color, sgf.EncodePoint(m.X, m.Y))
+		nodes = append(nodes, node)
+	}
+	comments := make([][]string, len(nodes))
+	for _, c := range chat {
+		i := c.Line.MoveNumber
+		if i < 0 || i >= len(nodes) {
+			//sent after a move that was taken back, or that isn't in GameData yet
+			i = len(nodes) - 1
+		}
+		line := fmt.Sprintf("%s: %s", c.Line.Username, c.Line.Body)
+		if c.Channel != ChatMain && c.Channel != "" {
+			line = fmt.Sprintf("(%s) %s", c.Channel, line)
+		}
+		comments[i] = append(comments[i], line)
+	}
+	for i, lines := range comments {
+		if len(lines) > 0 {
+			nodes[i].Set("C", strings.Join(lines, "\n"))
+		}
+	}
+	return root, nil
+}
+
+//sgfRank formats a player's rank the way SGF files usually do, e.g. 3k or 2d.
+func sgfRank(p Player) string {
+	return rankAbbreviations.Replace(p.Ranking())
+}
+
+var rankAbbreviations = strings.NewReplacer(" kyu", "k", " dan", "d")
+
+//mainTime returns the main time in seconds, before any overtime, or 0 if the time control has none.
+func (t TimeControl) mainTime() int {
+	switch t.System {
+	case "byoyomi", "canadian":
+		return t.MainTime
+	case "fischer":
+		return t.InitialTime
+	case "absolute":
+		return t.TotalTime
+	}
+	return 0
+}
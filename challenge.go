@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//The challenge page is a form to offer a new game, either to a specific player or as an open challenge.
+
+var challengeForm *tview.Form
+var challengeFrame *tview.Frame
+
+var challengeSizes = []string{"19x19", "13x13", "9x9"}
+var challengeRules = []string{"japanese", "chinese", "aga", "korean", "nz", "ing"}
+var challengeColors = []string{"automatic", "black", "white", "random"}
+
+//challengeTimeControls are the time controls that can be picked in the form.
+var challengeTimeControls = []api.TimeControl{
+	{System: "byoyomi", Speed: "live", MainTime: 600, PeriodTime: 30, Periods: 5},
+	{System: "byoyomi", Speed: "blitz", MainTime: 30, PeriodTime: 10, Periods: 5},
+	{System: "fischer", Speed: "live", InitialTime: 300, TimeIncrement: 10, MaxTime: 600},
+	{System: "fischer", Speed: "correspondence", InitialTime: 3 * 86400, TimeIncrement: 86400, MaxTime: 7 * 86400},
+}
+
+const challengeHint = "Leave the opponent empty for an open challenge. Esc: back"
+
+func newChallengePage() *tview.Frame {
+	timeControls := make([]string, len(challengeTimeControls))
+	for i, tc := range challengeTimeControls {
+		timeControls[i] = fmt.Sprintf("%s (%s)", tc, tc.Speed)
+	}
+	challengeForm = tview.NewForm()
+	challengeForm.
+		AddInputField("Opponent", "", 32, nil, nil).
+		AddInputField("Game name", "Friendly Match", 32, nil, nil).
+		AddDropDown("Board size", challengeSizes, 0, nil).
+		AddDropDown("Rules", challengeRules, 0, nil).
+		AddDropDown("Your color", challengeColors, 0, nil).
+		AddCheckbox("Ranked", true, nil).
+		AddInputField("Handicap", "0", 4, tview.InputFieldInteger, nil).
+		AddInputField("Komi", "", 6, nil, nil).
+		AddDropDown("Time control", timeControls, 0, nil).
+		AddButton("Create", createChallenge).
+		AddButton("Cancel", func() {
+			rootPage.SwitchToPage("browser")
+		}).
+		SetCancelFunc(func() {
+			rootPage.SwitchToPage("browser")
+		})
+	challengeFrame = tview.NewFrame(challengeForm)
+	challengeFrame.SetBorders(0, 0, 0, 0, 1, 0)
+	setChallengeStatus("", tcell.ColorDefault)
+	return challengeFrame
+}
+
+//setChallengeStatus shows text above the challenge form.
+func setChallengeStatus(text string, color tcell.Color) {
+	challengeFrame.Clear().
+		AddText("New challenge", true, tview.AlignLeft, tcell.PaletteColor(3)).
+		AddText(challengeHint, false, tview.AlignLeft, tcell.ColorDefault)
+	if text != "" {
+		challengeFrame.AddText(text, true, tview.AlignLeft, color)
+	}
+}
+
+//challengeRequest builds a challenge from the form. The opponent's username is returned separately,
+//as it still needs to be looked up.
+func challengeRequest() (api.ChallengeRequest, string, error) {
+	text := func(label string) string {
+		return strings.TrimSpace(challengeForm.GetFormItemByLabel(label).(*tview.InputField).GetText())
+	}
+	option := func(label string) int {
+		i, _ := challengeForm.GetFormItemByLabel(label).(*tview.DropDown).GetCurrentOption()
+		return i
+	}
+	req := api.ChallengeRequest{
+		Name:        text("Game name"),
+		Rules:       challengeRules[option("Rules")],
+		Color:       challengeColors[option("Your color")],
+		Ranked:      challengeForm.GetFormItemByLabel("Ranked").(*tview.Checkbox).IsChecked(),
+		TimeControl: challengeTimeControls[option("Time control")],
+	}
+	fmt.Sscanf(challengeSizes[option("Board size")], "%dx%d", &req.Width, &req.Height)
+	handicap, err := strconv.Atoi(text("Handicap"))
+	if err != nil {
+		return req, "", fmt.Errorf("Invalid handicap: %w", err)
+	}
+	req.Handicap = handicap
+	if komi := text("Komi"); komi != "" {
+		k, err := strconv.ParseFloat(komi, 64)
+		if err != nil {
+			return req, "", fmt.Errorf("Invalid komi: %w", err)
+		}
+		req.Komi = &k
+	}
+	return req, text("Opponent"), nil
+}
+
+func createChallenge() {
+	req, opponent, err := challengeRequest()
+	if err != nil {
+		setChallengeStatus(err.Error(), tcell.PaletteColor(1))
+		return
+	}
+	async(func(ctx context.Context) {
+		if opponent != "" {
+			player, err := ogs.FindPlayer(ctx, opponent)
+			if err != nil {
+				setChallengeStatus(err.Error(), tcell.PaletteColor(1))
+				return
+			}
+			req.OpponentID = player.ID
+		}
+		created, err := ogs.CreateChallenge(ctx, req)
+		if err != nil {
+			setChallengeStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		if opponent != "" {
+			setChallengeStatus(fmt.Sprintf("Challenged %s; game %d starts once they accept.", opponent, created.GameID), tcell.PaletteColor(2))
+		} else {
+			setChallengeStatus(fmt.Sprintf("Open challenge created; game %d starts once someone accepts.", created.GameID), tcell.PaletteColor(2))
+		}
+	})
+}
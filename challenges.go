@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//The challenges page lists the challenges other players sent to the logged in user.
+//Accepting one opens its game right away.
+
+var challengeList *tview.List
+var challengesFrame *tview.Frame
+var challenges []api.Challenge //challenges shown in challengeList, in the same order
+
+const challengesHint = "Return: accept, d: decline, r: refresh, q: back"
+
+func newChallengesPage() *tview.Frame {
+	challengeList = tview.NewList()
+	challengesFrame = tview.NewFrame(challengeList)
+	challengesFrame.SetBorders(0, 0, 0, 0, 0, 0)
+	challengeList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			return event
+		}
+		switch event.Rune() {
+		case 'q':
+			refreshGames()
+			rootPage.SwitchToPage("browser")
+		case 'r':
+			refreshChallenges()
+		case 'd':
+			declineChallenge(challengeList.GetCurrentItem())
+		default:
+			return event
+		}
+		return nil
+	})
+	return challengesFrame
+}
+
+//incomingChallenges returns the challenges sent to the logged in user.
+func incomingChallenges(ctx context.Context) ([]api.Challenge, error) {
+	all, err := ogs.GetChallenges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var incoming []api.Challenge
+	for _, c := range all {
+		if c.Challenged != nil && c.Challenged.ID == ogs.AuthData.Player.ID {
+			incoming = append(incoming, c)
+		}
+	}
+	return incoming, nil
+}
+
+//refreshChallenges loads the challenges sent to the logged in user into the challenges page.
+func refreshChallenges() {
+	challengeList.Clear()
+	challenges = nil
+	setChallengesStatus("", tcell.ColorDefault)
+	async(func(ctx context.Context) {
+		incoming, err := incomingChallenges(ctx)
+		if err != nil {
+			setChallengesStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		challenges = incoming
+		for _, c := range incoming {
+			c := c
+			challengeList.AddItem(c.Game.Name, c.Description(), 0, func() {
+				acceptChallenge(c)
+			})
+		}
+		if len(incoming) == 0 {
+			setChallengesStatus("Nobody has challenged you.", tcell.ColorDefault)
+		}
+	})
+}
+
+//setChallengesStatus shows text above the challenges list.
+func setChallengesStatus(text string, color tcell.Color) {
+	challengesFrame.Clear().
+		AddText("Incoming challenges", true, tview.AlignLeft, tcell.PaletteColor(3)).
+		AddText(challengesHint, false, tview.AlignLeft, tcell.ColorDefault)
+	if text != "" {
+		challengesFrame.AddText(text, true, tview.AlignLeft, color)
+	}
+}
+
+//acceptChallenge accepts a challenge and opens its game.
+func acceptChallenge(c api.Challenge) {
+	async(func(ctx context.Context) {
+		if err := ogs.AcceptChallenge(ctx, c.ID); err != nil {
+			setChallengesStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		if err := enterGame(ctx, c.Game.ID, "browser"); err != nil {
+			showError(err)
+		}
+	})
+}
+
+//declineChallenge declines the i-th challenge and removes it from the list.
+func declineChallenge(i int) {
+	if i < 0 || i >= len(challenges) {
+		return
+	}
+	c := challenges[i]
+	async(func(ctx context.Context) {
+		if err := ogs.DeclineChallenge(ctx, c.ID); err != nil {
+			setChallengesStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		setChallengesStatus(fmt.Sprintf("Declined the challenge from %s.", c.Challenger.Username), tcell.ColorDefault)
+		challenges = append(challenges[:i:i], challenges[i+1:]...)
+		challengeList.RemoveItem(i)
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lvank/termsuji/api"
+	"github.com/lvank/termsuji/sgf"
+)
+
+//Besides the user interface, termsuji has a few commands that run from the command line and exit:
+//
+//	termsuji export <game id> [file]   save a game as an SGF file, or print it if file is -
+//	termsuji view <file>               browse an SGF file, without logging in
+
+const usage = "usage: termsuji [export <game id> [file] | view <file>]"
+
+//chatQuietTime is how long exportChat waits for more chat messages before assuming it has the whole history.
+const chatQuietTime = 2 * time.Second
+
+//runCommand runs the command line command given by args.
+func runCommand(args []string) error {
+	switch args[0] {
+	case "export":
+		return exportCommand(args[1:])
+	case "view":
+		return viewCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	}
+	return errors.New(usage)
+}
+
+//exportCommand downloads a game and saves it as an SGF file.
+func exportCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New(usage)
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid game ID: %s", args[0])
+	}
+	path := sgfFileName(gameID)
+	if len(args) == 2 {
+		path = args[1]
+	}
+	showError = func(err error) {
+		fmt.Fprintf(os.Stderr, "termsuji: %s\n", err)
+	}
+	//a login is only needed for private games
+	auth, errs := login()
+	for _, err := range errs {
+		showError(err)
+	}
+	//logging in rotated the refresh token, so the stored one is no longer valid
+	if ogs.Auth().Authenticated {
+		if err := storeAuthData(auth); err != nil {
+			showError(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	details, err := ogs.GetGameDetails(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	root, err := details.SGF(exportChat(ctx, gameID))
+	if err != nil {
+		return err
+	}
+	if err := writeSGF(root, path); err != nil {
+		return err
+	}
+	if path != "-" {
+		fmt.Printf("Saved game %d to %s\n", gameID, path)
+	}
+	return nil
+}
+
+//exportChat returns the chat of a game. OGS only sends it over the realtime API, as a history right after
+//joining the game, so this waits until no more messages arrive. Chat is optional in an export, so on errors
+//whatever was received is returned.
+func exportChat(ctx context.Context, gameID int64) []api.OnChatResult {
+	realtimeClient, err := ogs.Realtime(ctx)
+	if err != nil {
+		return nil
+	}
+	defer realtimeClient.Disconnect()
+	var mu sync.Mutex
+	var lines []api.OnChatResult
+	var loaded bool
+	last := time.Now()
+	game, err := realtimeClient.Join(ctx, gameID, true, func(*api.GameData) {
+		mu.Lock()
+		loaded = true
+		last = time.Now()
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil
+	}
+	defer game.Leave()
+	game.OnChat(func(r api.OnChatResult) {
+		mu.Lock()
+		lines = append(lines, r)
+		last = time.Now()
+		mu.Unlock()
+	})
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			mu.Lock()
+			done := loaded && time.Since(last) >= chatQuietTime
+			mu.Unlock()
+			if !done {
+				continue
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return lines
+	}
+}
+
+//saveGame saves the game in the game view as an SGF file in the working directory.
+func saveGame() {
+	root, err := gameBoard.SGF()
+	path := sgfFileName(gameBoard.GameID())
+	if err == nil {
+		err = writeSGF(root, path)
+	}
+	if err != nil {
+		showError(err)
+		return
+	}
+	gameBoard.Notify(fmt.Sprintf("Saved the game to %s.", path))
+}
+
+//sgfFileName returns the default file name for the SGF file of a game.
+func sgfFileName(gameID int64) string {
+	return fmt.Sprintf("ogs-%d.sgf", gameID)
+}
+
+//writeSGF writes a game tree to a file, or to stdout if path is -.
+func writeSGF(root *sgf.Node, path string) error {
+	if path == "-" {
+		return sgf.Write(os.Stdout, root)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := sgf.Write(f, root); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -55,14 +55,20 @@ type Config struct {
 	Theme Theme `json:"theme"`
 }
 
+//InitConfig reads the configuration file, if there is one. If it can't be read or is invalid,
+//the default configuration is returned along with the error, so the application can keep running.
 func InitConfig() (*Config, error) {
 	config := DefaultConfig
 	absPath, err := xdg.SearchConfigFile(cfgFile)
 	if err == nil {
-		readCfgFile(absPath, &config)
-	}
-	if err = config.Validate(); err != nil {
-		return nil, err
+		err = readCfgFile(absPath, &config)
+		if err == nil {
+			err = config.Validate()
+		}
+		if err != nil {
+			config = DefaultConfig
+			return &config, err
+		}
 	}
 	return &config, nil
 }
@@ -76,12 +82,12 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-func (c *Config) Save() {
+func (c *Config) Save() error {
 	absPath, err := xdg.ConfigFile(cfgFile)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	saveCfgFile(absPath, c, 0664)
+	return saveCfgFile(absPath, c, 0664)
 }
 
 type AuthData struct {
@@ -92,40 +98,42 @@ type AuthData struct {
 	} `json:"tokens"`
 }
 
-func InitAuthData() *AuthData {
+//InitAuthData reads the stored authentication data, if there is any. If it can't be read,
+//empty authentication data is returned along with the error.
+func InitAuthData() (*AuthData, error) {
 	authData := AuthData{}
 	absPath, err := xdg.SearchStateFile(authFile)
 	if err == nil {
-		readCfgFile(absPath, &authData)
+		if err = readCfgFile(absPath, &authData); err != nil {
+			return &AuthData{}, err
+		}
 	}
-	return &authData
+	return &authData, nil
 }
 
-func (a *AuthData) Save() {
+func (a *AuthData) Save() error {
 	absPath, err := xdg.StateFile(authFile)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	saveCfgFile(absPath, a, 0600)
+	return saveCfgFile(absPath, a, 0600)
 }
 
-func saveCfgFile(filePath string, a interface{}, perm fs.FileMode) {
+func saveCfgFile(filePath string, a interface{}, perm fs.FileMode) error {
 	jsonData, err := json.MarshalIndent(a, "", "  ")
 	if err != nil {
-		panic(err)
-	}
-	err = os.WriteFile(filePath, jsonData, perm)
-	if err != nil {
-		panic(err)
+		return err
 	}
+	return os.WriteFile(filePath, jsonData, perm)
 }
 
-func readCfgFile(filePath string, a interface{}) {
+func readCfgFile(filePath string, a interface{}) error {
 	configReader, err := os.ReadFile(filePath)
-	if err == nil {
-		err = json.Unmarshal(configReader, &a)
-		if err != nil {
-			panic(err)
-		}
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(configReader, &a); err != nil {
+		return &InvalidConfig{fmt.Sprintf("%s: %s", filePath, err)}
 	}
+	return nil
 }
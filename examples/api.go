@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/lvank/termsuji/api"
@@ -8,16 +9,27 @@ import (
 
 // an example
 func APIExample() {
+	ogs := api.NewClient()
+	//Every call takes a context, which can be used to cancel it or to set a deadline.
+	ctx := context.Background()
+	//To use another server, e.g. the beta site, change the base URL:
+	//ogs.BaseURL = "https://beta.online-go.com"
+
 	//You can only authenticate with a refresh token if you have one stored from an earlier password authentication.
-	if err := api.AuthenticateRefreshToken("YourStoredRefreshToken"); err != nil {
+	if err := ogs.AuthenticateRefreshToken(ctx, "YourStoredRefreshToken"); err != nil {
 		//Set a password from OGS > Settings > Account Settings
-		if err = api.AuthenticatePassword("YourOGSUsername", "YourOGSPassword"); err != nil {
+		if err = ogs.AuthenticatePassword(ctx, "YourOGSUsername", "YourOGSPassword"); err != nil {
 			panic("Username and password incorrect")
 		}
 	}
-	fmt.Printf("Hello, %s!", api.AuthData.Player.Username)
+	fmt.Printf("Hello, %s!", ogs.AuthData.Player.Username)
 	fmt.Println("Active games:")
-	games := api.GetGamesList()
+	games, err := ogs.GetGamesList(ctx)
+	if err != nil {
+		//Errors are one of api.NetworkError, api.OGSApiError or api.DecodeError.
+		//errors.Is(err, api.ErrUnauthorized) can be used to check if you need to authenticate again.
+		panic(err)
+	}
 	var game api.GameListData //store the last active game
 	for i := range games.Games {
 		game = games.Games[i]
@@ -28,10 +40,16 @@ func APIExample() {
 		fmt.Printf("%s\n==> %s\n", game.Name, game.Description())
 	}
 	fmt.Println("Single game:")
-	gameDetails := api.GetGameData(game.ID)
+	gameDetails, err := ogs.GetGameData(ctx, game.ID)
+	if err != nil {
+		panic(err)
+	}
 	fmt.Printf("%#v", gameDetails)
 
 	fmt.Println("Board state for that game:")
-	boardState := api.GetGameState(game.ID)
+	boardState, err := ogs.GetGameState(ctx, game.ID)
+	if err != nil {
+		panic(err)
+	}
 	fmt.Printf("%#v", boardState)
 }
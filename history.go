@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//The history page lists all games of the logged in user, newest first. Pages are loaded from OGS
+//as the selection reaches the end of the list.
+
+var historyList *tview.List
+var historyFrame *tview.Frame
+var historyPager *api.GamePager
+var historyQuery = api.GameQuery{Status: api.AllGames}
+var historyLoading bool
+var historySizes = []int{0, 9, 13, 19} //board sizes to cycle through, 0 means any size
+
+const historyHint = "a: active/ended/all, s: board size, r: refresh, q: back"
+
+func newHistoryPage() *tview.Frame {
+	historyList = tview.NewList()
+	historyFrame = tview.NewFrame(historyList)
+	historyFrame.SetBorders(0, 0, 0, 0, 0, 0)
+	historyList.SetChangedFunc(func(index int, main, secondary string, shortcut rune) {
+		if index == historyList.GetItemCount()-1 {
+			loadHistoryPage()
+		}
+	})
+	historyList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			return event
+		}
+		switch event.Rune() {
+		case 'q':
+			rootPage.SwitchToPage("browser")
+		case 'r':
+			refreshHistory()
+		case 'a':
+			historyQuery.Status = (historyQuery.Status + 1) % 3
+			refreshHistory()
+		case 's':
+			for i, size := range historySizes {
+				if size == historyQuery.Width {
+					historyQuery.Width = historySizes[(i+1)%len(historySizes)]
+					break
+				}
+			}
+			historyQuery.Height = historyQuery.Width
+			refreshHistory()
+		default:
+			return event
+		}
+		return nil
+	})
+	return historyFrame
+}
+
+//refreshHistory clears the history page and loads the first page of games for historyQuery.
+func refreshHistory() {
+	historyList.Clear()
+	historyPager = ogs.Games(historyQuery)
+	historyLoading = false
+	updateHistoryHint()
+	loadHistoryPage()
+}
+
+//loadHistoryPage appends the next page of games to the history page, if there is one.
+//Pages without matching games are skipped.
+func loadHistoryPage() {
+	pager := historyPager
+	if historyLoading || !pager.HasNext() {
+		return
+	}
+	historyLoading = true
+	async(func(ctx context.Context) {
+		defer func() {
+			historyLoading = false
+		}()
+		added := 0
+		for added == 0 && pager.HasNext() {
+			games, err := pager.Next(ctx)
+			if err != nil {
+				showError(err)
+				return
+			}
+			if pager != historyPager {
+				//the query changed while loading
+				return
+			}
+			for _, game := range games {
+				gameID := game.ID
+				historyList.AddItem(game.Name, fmt.Sprintf("%s, started %s", game.Description(), game.Started.Format("2006-01-02")), 0, func() {
+					openGame(gameID, "history")
+				})
+			}
+			added += len(games)
+		}
+		updateHistoryHint()
+	})
+}
+
+func updateHistoryHint() {
+	size := "any size"
+	if historyQuery.Width > 0 {
+		size = fmt.Sprintf("%dx%d", historyQuery.Width, historyQuery.Height)
+	}
+	more := ""
+	if historyPager.HasNext() {
+		more = ", scroll down for more"
+	}
+	historyFrame.Clear().
+		AddText(fmt.Sprintf("Game history: %s games, %s (%d loaded%s)", historyQuery.Status, size, historyList.GetItemCount(), more), true, tview.AlignLeft, tcell.PaletteColor(3)).
+		AddText(historyHint, false, tview.AlignLeft, tcell.ColorDefault)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//The live games page lists public games that are currently being played, which can be opened to spectate them.
+//Like the history page, more games are loaded as the selection reaches the end of the list.
+
+var liveList *tview.List
+var liveFrame *tview.Frame
+var liveQuery = api.LiveGameQuery{}
+var liveNext int   //index of the first game of the next page
+var liveTotal = -1 //number of games in the OGS list, -1 before the first page is loaded
+var liveLoading bool
+var liveGeneration int              //incremented on refresh, so pages for an old query are dropped
+var liveSizes = []int{0, 9, 13, 19} //board sizes to cycle through, 0 means any size
+//rank filters to cycle through, split where Player.Ranking rounds so each game lands in the bucket its ranks are shown as
+var liveRanks = []struct {
+	name     string
+	min, max float32
+}{
+	{"any rank", 0, 0},
+	{"dan players", 30, 0},
+	{"single digit kyu", math.Nextafter32(api.MaxRawRankingForKyu(10), 30), math.Nextafter32(30, 0)},
+	{"double digit kyu", 0, api.MaxRawRankingForKyu(10)},
+}
+var liveRank int //index into liveRanks
+
+const liveHint = "c: live/correspondence, s: board size, k: rank, r: refresh, q: back"
+
+func newLivePage() *tview.Frame {
+	liveList = tview.NewList()
+	liveFrame = tview.NewFrame(liveList)
+	liveFrame.SetBorders(0, 0, 0, 0, 0, 0)
+	liveList.SetChangedFunc(func(index int, main, secondary string, shortcut rune) {
+		if index == liveList.GetItemCount()-1 {
+			loadLivePage()
+		}
+	})
+	liveList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			return event
+		}
+		switch event.Rune() {
+		case 'q':
+			rootPage.SwitchToPage("browser")
+		case 'r':
+			refreshLive()
+		case 'c':
+			liveQuery.Correspondence = !liveQuery.Correspondence
+			refreshLive()
+		case 's':
+			for i, size := range liveSizes {
+				if size == liveQuery.Size {
+					liveQuery.Size = liveSizes[(i+1)%len(liveSizes)]
+					break
+				}
+			}
+			refreshLive()
+		case 'k':
+			liveRank = (liveRank + 1) % len(liveRanks)
+			liveQuery.MinRank, liveQuery.MaxRank = liveRanks[liveRank].min, liveRanks[liveRank].max
+			refreshLive()
+		default:
+			return event
+		}
+		return nil
+	})
+	return liveFrame
+}
+
+//refreshLive clears the live games page and loads the first page of games for liveQuery.
+func refreshLive() {
+	liveList.Clear()
+	liveNext = 0
+	liveTotal = -1
+	liveLoading = false
+	liveGeneration++
+	updateLiveHint()
+	loadLivePage()
+}
+
+//loadLivePage appends the next page of games to the live games page, if there is one.
+//Pages without matching games are skipped.
+func loadLivePage() {
+	if liveLoading || (liveTotal >= 0 && liveNext >= liveTotal) {
+		return
+	}
+	liveLoading = true
+	generation := liveGeneration
+	async(func(ctx context.Context) {
+		defer func() {
+			liveLoading = false
+		}()
+		realtimeClient, err := ogs.Realtime(ctx)
+		if err != nil {
+			showError(err)
+			return
+		}
+		added := 0
+		for added == 0 && (liveTotal < 0 || liveNext < liveTotal) {
+			q := liveQuery
+			q.From = liveNext
+			page, err := realtimeClient.LiveGames(ctx, q)
+			if err != nil {
+				showError(err)
+				return
+			}
+			if generation != liveGeneration {
+				//the query changed while loading
+				return
+			}
+			liveTotal = page.Size
+			liveNext = page.From + page.Limit
+			if page.Limit == 0 || page.Returned == 0 {
+				//OGS has no more games, even if Size says otherwise; asking again wouldn't get any further
+				liveTotal = liveNext
+			}
+			for _, game := range page.Results {
+				gameID := game.ID
+				liveList.AddItem(game.Name, game.Description(), 0, func() {
+					openGame(gameID, "live")
+				})
+			}
+			added += len(page.Results)
+		}
+		updateLiveHint()
+	})
+}
+
+func updateLiveHint() {
+	list := "live"
+	if liveQuery.Correspondence {
+		list = "correspondence"
+	}
+	size := "any size"
+	if liveQuery.Size > 0 {
+		size = fmt.Sprintf("%dx%d", liveQuery.Size, liveQuery.Size)
+	}
+	more := ""
+	if liveTotal < 0 || liveNext < liveTotal {
+		more = ", scroll down for more"
+	}
+	liveFrame.Clear().
+		AddText(fmt.Sprintf("Public %s games: %s, %s (%d loaded%s)", list, size, liveRanks[liveRank].name, liveList.GetItemCount(), more), true, tview.AlignLeft, tcell.PaletteColor(3)).
+		AddText(liveHint, false, tview.AlignLeft, tcell.ColorDefault)
+}
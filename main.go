@@ -4,7 +4,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -15,6 +20,7 @@ import (
 )
 
 var lastRefresh time.Time = time.Now()
+var ogs *api.Client
 var app *tview.Application
 var rootPage *tview.Pages
 var gameListFrame *tview.Frame
@@ -22,17 +28,41 @@ var gameList *tview.List
 var frameHint *tview.Frame
 var gameBoard *ui.GoBoardUI
 var setLoading func(bool)
+var setLoadingText func(string) //changes the text of the loading screen until it is reset with loadingText
+var showError func(error)
+var cancelLoading context.CancelFunc = func() {}
+var gameReturnPage = "browser" //page to go back to when leaving the game view
+var watchMu sync.Mutex         //guards watchedGames and watchGeneration
+var watchedGames []*api.RealtimeGame
+var watchGeneration int //incremented whenever the watched games change, so stale updates to the game list are dropped
+
+const gameListHint = "r: refresh, n: new challenge, c: incoming challenges, s: seek graph, h: game history, l: live games, t: themes, q: quit"
+const loadingText = "Loading...\n\nEsc: cancel"
+
+//yourMoveMarker is shown in front of games in the game list where it is the user's move.
+const yourMoveMarker = "(your move) "
 
 func main() {
-	auth := config.InitAuthData()
-	if auth.Tokens.Refresh != "" {
-		api.AuthenticateRefreshToken(auth.Tokens.Refresh)
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "termsuji: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	//errors that occur before the UI is running are shown once it is
+	var startupErrors []string
+	auth, errs := login()
+	for _, err := range errs {
+		startupErrors = append(startupErrors, err.Error())
 	}
 	cfg, err := config.InitConfig()
 	if err != nil {
-		panic(err)
+		//keep the broken file around so it can be fixed; the defaults are used until then
+		startupErrors = append(startupErrors, err.Error())
+	} else if err = cfg.Save(); err != nil { // TODO settings screen or something
+		startupErrors = append(startupErrors, err.Error())
 	}
-	cfg.Save() // TODO settings screen or something
 	app = tview.NewApplication()
 	rootPage = tview.NewPages()
 	rootPage.SetBorder(true).SetTitle("termsuji")
@@ -41,7 +71,14 @@ func main() {
 	gameListFrame.SetBorders(0, 0, 0, 0, 0, 0)
 
 	loadingModal := tview.NewModal()
-	loadingModal.SetText("Loading...")
+	loadingModal.SetText(loadingText)
+	loadingModal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			cancelLoading()
+			return nil
+		}
+		return event
+	})
 	setLoading = func(loading bool) {
 		f := rootPage.ShowPage
 		if !loading {
@@ -49,23 +86,40 @@ func main() {
 		}
 		f("loading")
 	}
+	setLoadingText = func(text string) {
+		loadingModal.SetText(text)
+	}
+	errorModal := tview.NewModal()
+	errorModal.
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(int, string) {
+			rootPage.HidePage("error")
+		})
+	showError = func(err error) {
+		errorModal.SetText(err.Error())
+		rootPage.ShowPage("error")
+	}
 	gameFrame := tview.NewFlex()
 	gameHint := tview.NewTextView()
 	gameHint.SetBorder(true)
-	gameBoard = ui.NewGoBoard(app, cfg, gameHint)
+	gameBoard = ui.NewGoBoard(app, ogs, cfg, gameHint)
+	gameSidebar := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(gameBoard.Clock, 5, 0, false).
+		AddItem(gameHint, 0, 1, false).
+		AddItem(gameBoard.Chat, 0, 1, false)
 	gameFrame.
 		AddItem(gameBoard.Box, 20*2+3, 1, true).
-		AddItem(gameHint, 0, 2, false)
+		AddItem(gameSidebar, 0, 2, false)
 	gameBoard.Box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyRune && event.Rune() == 'q' {
 			if gameBoard.SelectedTile() != nil {
 				gameBoard.ResetSelection()
 			} else {
 				gameBoard.Close()
-				async(func() {
+				if gameReturnPage == "browser" {
 					refreshGames()
-					rootPage.SwitchToPage("browser")
-				})
+				}
+				rootPage.SwitchToPage(gameReturnPage)
 			}
 			return nil
 		}
@@ -78,16 +132,47 @@ func main() {
 			gameBoard.MoveSelection(-1, 0)
 		case tcell.KeyRight:
 			gameBoard.MoveSelection(1, 0)
+		case tcell.KeyHome:
+			gameBoard.ReviewStart()
+		case tcell.KeyEnd:
+			gameBoard.ReviewEnd()
 		case tcell.KeyEnter:
 			selTile := gameBoard.SelectedTile()
 			if selTile == nil {
 				return nil
 			}
-			gameBoard.PlayMove(selTile.X, selTile.Y)
+			if gameBoard.StoneRemoval() {
+				gameBoard.ToggleRemoved(selTile.X, selTile.Y)
+			} else {
+				gameBoard.PlayMove(selTile.X, selTile.Y)
+			}
 		case tcell.KeyRune:
 			switch event.Rune() {
 			case 'p':
 				gameBoard.PlayMove(-1, -1)
+			case 'c':
+				gameBoard.FocusChat()
+				return nil
+			case 'u':
+				gameBoard.RequestUndo()
+			case 'y':
+				gameBoard.AnswerUndo(true)
+			case 'n':
+				gameBoard.AnswerUndo(false)
+			case 'a':
+				gameBoard.AcceptRemoval()
+			case 'x':
+				gameBoard.RejectRemoval()
+			case 'R':
+				if !gameBoard.Finished() && !gameBoard.Spectating() {
+					rootPage.ShowPage("resign")
+				}
+			case ',':
+				gameBoard.ReviewBack()
+			case '.':
+				gameBoard.ReviewForward()
+			case 'e':
+				saveGame()
 			case 't':
 				rootPage.ShowPage("themes")
 			}
@@ -102,9 +187,25 @@ func main() {
 				app.Stop()
 				return nil
 			case 'r':
-				async(func() {
-					refreshGames()
-				})
+				refreshGames()
+				return nil
+			case 'h':
+				refreshHistory()
+				rootPage.SwitchToPage("history")
+				return nil
+			case 'l':
+				refreshLive()
+				rootPage.SwitchToPage("live")
+				return nil
+			case 'n':
+				rootPage.SwitchToPage("challenge")
+				return nil
+			case 'c':
+				refreshChallenges()
+				rootPage.SwitchToPage("challenges")
+				return nil
+			case 's':
+				openSeekGraph()
 				return nil
 			case 't':
 				rootPage.ShowPage("themes")
@@ -120,22 +221,37 @@ func main() {
 		AddInputField("Username", auth.Username, 32, nil, nil). //if we have a cached username, prefill it
 		AddPasswordField("Password", "", 32, '*', nil).
 		AddButton("Submit", func() {
-			err := api.AuthenticatePassword(
-				loginForm.GetFormItem(0).(*tview.InputField).GetText(),
-				loginForm.GetFormItem(1).(*tview.InputField).GetText(),
-			)
-			if err != nil {
-				loginFrame.Clear().AddText(err.Error(), true, tview.AlignLeft, tcell.PaletteColor(1))
-				return
-			}
-			storeAuthData(auth)
-			refreshGames()
-			rootPage.SwitchToPage("browser")
+			username := loginForm.GetFormItem(0).(*tview.InputField).GetText()
+			password := loginForm.GetFormItem(1).(*tview.InputField).GetText()
+			async(func(ctx context.Context) {
+				err := ogs.AuthenticatePassword(ctx, username, password)
+				if err != nil {
+					loginFrame.Clear().AddText(err.Error(), true, tview.AlignLeft, tcell.PaletteColor(1))
+					return
+				}
+				if err := storeAuthData(auth); err != nil {
+					showError(err)
+				}
+				refreshGames()
+				rootPage.SwitchToPage("browser")
+			})
 		})
 	loginFrame.
 		SetBorders(0, 0, 0, 0, 1, 0).
 		AddText("Log in to OGS", true, tview.AlignLeft, tcell.PaletteColor(3))
 
+	resignModal := tview.NewModal()
+	resignModal.
+		SetText("Are you sure you want to resign this game?").
+		AddButtons([]string{"Resign", "Cancel"}).
+		SetFocus(1).
+		SetDoneFunc(func(i int, label string) {
+			if label == "Resign" {
+				gameBoard.Resign()
+			}
+			rootPage.HidePage("resign")
+		})
+
 	themeList := tview.NewList()
 	themeList.SetTitle("Choose a theme")
 	selectTheme := func(i int, main, secondary string, shortcut rune) {
@@ -156,7 +272,9 @@ func main() {
 
 		if main != "quit" {
 			cfg.Theme = theme
-			cfg.Save()
+			if err := cfg.Save(); err != nil {
+				showError(err)
+			}
 			gameBoard.SetConfig(cfg)
 		}
 		rootPage.HidePage("themes")
@@ -172,17 +290,30 @@ func main() {
 
 	rootPage.AddPage("login", loginFrame, true, true)
 	rootPage.AddPage("browser", gameListFrame, true, false)
+	rootPage.AddPage("history", newHistoryPage(), true, false)
+	rootPage.AddPage("live", newLivePage(), true, false)
+	rootPage.AddPage("challenge", newChallengePage(), true, false)
+	rootPage.AddPage("challenges", newChallengesPage(), true, false)
+	rootPage.AddPage("seekgraph", newSeekGraphPage(), true, false)
+	rootPage.AddPage("automatch", newAutomatchPage(), true, false)
 	rootPage.AddPage("gameview", gameFrame, true, false)
 	rootPage.AddPage("themes", themeList, true, false)
+	rootPage.AddPage("resign", resignModal, false, false)
 	rootPage.AddPage("loading", loadingModal, false, false)
+	rootPage.AddPage("error", errorModal, false, false)
 
-	if api.AuthData.Authenticated {
-		storeAuthData(auth)
+	if ogs.Auth().Authenticated {
+		if err := storeAuthData(auth); err != nil {
+			startupErrors = append(startupErrors, err.Error())
+		}
 		refreshGames()
 		rootPage.SwitchToPage("browser")
 	} else {
 		rootPage.SwitchToPage("login")
 	}
+	if len(startupErrors) > 0 {
+		showError(errors.New(strings.Join(startupErrors, "\n\n")))
+	}
 
 	if err := app.SetRoot(rootPage, true).Run(); err != nil {
 		panic(err)
@@ -191,43 +322,178 @@ func main() {
 
 func refreshGames() {
 	gameList.Clear()
-	async(func() {
+	async(func(ctx context.Context) {
 		lastRefresh = time.Now()
-		gamesArray := api.GetGamesList()
-		i := 0
+		gameListFrame.Clear().AddText(gameListHint, false, tview.AlignLeft, tcell.ColorDefault)
+		gamesArray, err := ogs.GetGamesList(ctx)
+		if err != nil {
+			showGameListError(err)
+			return
+		}
+		var activeGames []api.GameListData
 		for _, game := range gamesArray.Games {
 			if game.GameOver() {
 				continue
 			}
 			gameID := game.ID
-			gameList.AddItem(game.Name, game.Description(), rune('1'+i), func() {
-				async(func() {
-					gameBoard.Connect(gameID)
-					rootPage.SwitchToPage("gameview")
-				})
+			gameList.AddItem(game.Name, game.Description(), rune('1'+len(activeGames)), func() {
+				openGame(gameID, "browser")
 			})
-			i++
+			activeGames = append(activeGames, game)
+		}
+		if err := watchGames(ctx, activeGames); err != nil {
+			showGameListError(err)
+			return
+		}
+		incoming, err := incomingChallenges(ctx)
+		if err != nil {
+			showGameListError(err)
+			return
+		}
+		if len(incoming) > 0 {
+			gameListFrame.AddText(fmt.Sprintf("You have %d incoming challenge(s), press c to see them.", len(incoming)), true, tview.AlignLeft, tcell.PaletteColor(3))
+		}
+	})
+}
+
+//watchGames joins the games shown in the game list on the realtime connection, to mark the ones
+//where it is the user's move. The markers are kept up to date until the game list is refreshed.
+func watchGames(ctx context.Context, games []api.GameListData) error {
+	unwatchGames()
+	realtimeClient, err := ogs.Realtime(ctx)
+	if err != nil {
+		return err
+	}
+	watchMu.Lock()
+	generation := watchGeneration
+	watchMu.Unlock()
+	for i, game := range games {
+		i, game := i, game
+		setYourMove := func(yourMove bool) {
+			app.QueueUpdateDraw(func() {
+				watchMu.Lock()
+				stale := generation != watchGeneration
+				watchMu.Unlock()
+				if stale || i >= gameList.GetItemCount() {
+					return
+				}
+				name := game.Name
+				if yourMove {
+					name = yourMoveMarker + name
+				}
+				gameList.SetItemText(i, name, game.Description())
+			})
+		}
+		watched, err := realtimeClient.Join(ctx, game.ID, false, func(gamedata *api.GameData) {
+			setYourMove(!gamedata.Finished() && gamedata.Clock.CurrentPlayerID == ogs.AuthData.Player.ID)
+		})
+		if err != nil {
+			return err
+		}
+		watched.OnClock(func(c api.OnClockResult) {
+			setYourMove(c.CurrentPlayerID == ogs.AuthData.Player.ID)
+		})
+		watchMu.Lock()
+		watchedGames = append(watchedGames, watched)
+		watchMu.Unlock()
+	}
+	return nil
+}
+
+//unwatchGames leaves the games joined by watchGames.
+func unwatchGames() {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	for _, watched := range watchedGames {
+		watched.Leave()
+	}
+	watchedGames = nil
+	watchGeneration++
+}
+
+//openGame connects to a game and shows it in the game view. Leaving the game view returns to returnPage.
+func openGame(gameID int64, returnPage string) {
+	async(func(ctx context.Context) {
+		if err := enterGame(ctx, gameID, returnPage); err != nil {
+			showError(err)
 		}
-		gameListHint := fmt.Sprintf("r: refresh, t: themes, q: quit")
-		gameListFrame.Clear().AddText(gameListHint, false, tview.AlignLeft, tcell.ColorDefault)
 	})
 }
 
+//enterGame is openGame for callers that are already running in async.
+func enterGame(ctx context.Context, gameID int64, returnPage string) error {
+	if err := gameBoard.Connect(ctx, gameID); err != nil {
+		return err
+	}
+	gameReturnPage = returnPage
+	rootPage.SwitchToPage("gameview")
+	return nil
+}
+
+//showGameListError displays err above the game list. If the error was caused by an expired
+//session, the login page is shown instead.
+func showGameListError(err error) {
+	if errors.Is(err, api.ErrUnauthorized) {
+		rootPage.SwitchToPage("login")
+		return
+	}
+	gameListFrame.Clear().
+		AddText(err.Error(), true, tview.AlignLeft, tcell.PaletteColor(1)).
+		AddText(gameListHint, false, tview.AlignLeft, tcell.ColorDefault)
+}
+
 //Helper function to show a loading screen while blocking functions are being called.
-func async(f func()) {
+//f receives a context that is cancelled when the user presses Esc on the loading screen.
+func async(f func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelLoading = cancel
 	go func() {
+		defer cancel()
 		setLoading(true)
 		app.Draw()
-		f()
+		f(ctx)
 		setLoading(false)
 		app.Draw()
 	}()
 }
 
+//login creates the OGS client and logs in with the stored refresh token, if there is one.
+//The returned errors don't prevent using termsuji; at worst the user has to log in again.
+func login() (*config.AuthData, []error) {
+	var errs []error
+	ogs = api.NewClient()
+	auth, err := config.InitAuthData()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	//OGS rotates the refresh token whenever the access token is refreshed, so store the new one
+	ogs.OnTokenRefresh = func(api.OauthResponse) {
+		err := storeAuthData(auth)
+		switch {
+		case err == nil:
+		case showError == nil:
+			//the UI isn't set up yet, so the terminal can still be written to
+			fmt.Fprintf(os.Stderr, "termsuji: %s\n", err)
+		default:
+			showError(err)
+		}
+	}
+	if auth.Tokens.Refresh != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = ogs.AuthenticateRefreshToken(ctx, auth.Tokens.Refresh)
+		cancel()
+		if err != nil && err != api.InvalidRefreshToken {
+			errs = append(errs, err)
+		}
+	}
+	return auth, errs
+}
+
 //Stores authentication data from api package after successful authentication.
-func storeAuthData(a *config.AuthData) {
-	a.Username = api.AuthData.Player.Username
-	a.UserID = api.AuthData.Player.ID
-	a.Tokens.Refresh = api.AuthData.Oauth.RefreshToken
-	a.Save()
+func storeAuthData(a *config.AuthData) error {
+	info := ogs.Auth()
+	a.Username = info.Player.Username
+	a.UserID = info.Player.ID
+	a.Tokens.Refresh = info.Oauth.RefreshToken
+	return a.Save()
 }
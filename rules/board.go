@@ -0,0 +1,191 @@
+//Package rules implements the rules of Go needed to follow a game locally: placing stones,
+//captures, suicide, ko and optionally positional or situational superko.
+//Coordinates follow OGS: x runs from left to right and y from top to bottom, starting at 0.
+package rules
+
+import "fmt"
+
+//Color is the content of a point on the board. The values match the ones used by OGS in BoardState.
+type Color int
+
+const (
+	Empty Color = iota
+	Black
+	White
+)
+
+//Opponent returns the other player's color. Empty stays Empty.
+func (c Color) Opponent() Color {
+	switch c {
+	case Black:
+		return White
+	case White:
+		return Black
+	}
+	return Empty
+}
+
+func (c Color) String() string {
+	switch c {
+	case Black:
+		return "black"
+	case White:
+		return "white"
+	}
+	return "empty"
+}
+
+//Point is a position on the board.
+type Point struct {
+	X int
+	Y int
+}
+
+//Pass is the Point used to represent passing, matching the OGS representation.
+var Pass = Point{X: -1, Y: -1}
+
+//IsPass returns true if p represents a pass.
+func (p Point) IsPass() bool {
+	return p.X < 0 || p.Y < 0
+}
+
+func (p Point) String() string {
+	if p.IsPass() {
+		return "pass"
+	}
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+//Board is a rectangular Go board without any game state.
+type Board struct {
+	Width  int
+	Height int
+	cells  []Color
+}
+
+//NewBoard returns an empty board of the given size.
+func NewBoard(width, height int) *Board {
+	return &Board{Width: width, Height: height, cells: make([]Color, width*height)}
+}
+
+//BoardFromGrid creates a board from the OGS representation, a 2D array indexed as grid[y][x]
+//containing 0 for empty points, 1 for black and 2 for white stones.
+func BoardFromGrid(grid [][]int) *Board {
+	height, width := len(grid), 0
+	if height > 0 {
+		width = len(grid[0])
+	}
+	b := NewBoard(width, height)
+	for y, row := range grid {
+		for x, c := range row {
+			if x < width {
+				b.Set(Point{x, y}, Color(c))
+			}
+		}
+	}
+	return b
+}
+
+//Grid returns the board in the OGS representation, indexed as grid[y][x].
+func (b *Board) Grid() [][]int {
+	grid := make([][]int, b.Height)
+	for y := range grid {
+		grid[y] = make([]int, b.Width)
+		for x := range grid[y] {
+			grid[y][x] = int(b.At(Point{x, y}))
+		}
+	}
+	return grid
+}
+
+//OnBoard returns true if p lies on the board.
+func (b *Board) OnBoard(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < b.Width && p.Y < b.Height
+}
+
+//At returns the color at p, or Empty if p lies outside of the board.
+func (b *Board) At(p Point) Color {
+	if !b.OnBoard(p) {
+		return Empty
+	}
+	return b.cells[p.Y*b.Width+p.X]
+}
+
+//Set changes the color at p without applying any rules. Points outside of the board are ignored.
+func (b *Board) Set(p Point, c Color) {
+	if b.OnBoard(p) {
+		b.cells[p.Y*b.Width+p.X] = c
+	}
+}
+
+//Clone returns a copy of the board.
+func (b *Board) Clone() *Board {
+	clone := *b
+	clone.cells = append([]Color(nil), b.cells...)
+	return &clone
+}
+
+//Equal returns true if both boards have the same size and stones.
+func (b *Board) Equal(o *Board) bool {
+	return b.Width == o.Width && b.Height == o.Height && b.key() == o.key()
+}
+
+//key returns a string uniquely identifying the stones on the board, used to detect repeated positions.
+func (b *Board) key() string {
+	k := make([]byte, len(b.cells))
+	for i, c := range b.cells {
+		k[i] = byte(c)
+	}
+	return string(k)
+}
+
+//Neighbors returns the points orthogonally adjacent to p that lie on the board.
+func (b *Board) Neighbors(p Point) []Point {
+	neighbors := make([]Point, 0, 4)
+	for _, n := range []Point{{p.X - 1, p.Y}, {p.X + 1, p.Y}, {p.X, p.Y - 1}, {p.X, p.Y + 1}} {
+		if b.OnBoard(n) {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors
+}
+
+//Group returns all stones connected to the stone at p, including p itself.
+//It returns nil if there is no stone at p.
+func (b *Board) Group(p Point) []Point {
+	c := b.At(p)
+	if c == Empty {
+		return nil
+	}
+	group := []Point{p}
+	seen := map[Point]bool{p: true}
+	for i := 0; i < len(group); i++ {
+		for _, n := range b.Neighbors(group[i]) {
+			if !seen[n] && b.At(n) == c {
+				seen[n] = true
+				group = append(group, n)
+			}
+		}
+	}
+	return group
+}
+
+//Liberties returns the number of distinct empty points adjacent to the group.
+func (b *Board) Liberties(group []Point) int {
+	liberties := make(map[Point]bool)
+	for _, p := range group {
+		for _, n := range b.Neighbors(p) {
+			if b.At(n) == Empty {
+				liberties[n] = true
+			}
+		}
+	}
+	return len(liberties)
+}
+
+//remove clears all points in group.
+func (b *Board) remove(group []Point) {
+	for _, p := range group {
+		b.Set(p, Empty)
+	}
+}
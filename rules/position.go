@@ -0,0 +1,240 @@
+package rules
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrOutOfBounds = errors.New("That point is not on the board")
+	ErrOccupied    = errors.New("There is already a stone there")
+	ErrSuicide     = errors.New("That move would be suicide")
+	ErrKo          = errors.New("That move would retake a ko")
+	ErrSuperko     = errors.New("That move would repeat an earlier position")
+)
+
+//Superko is a variant of the superko rule, which forbids repeating earlier positions.
+type Superko int
+
+const (
+	NoSuperko          Superko = iota //only simple ko
+	PositionalSuperko                 //no move may repeat an earlier board position
+	SituationalSuperko                //no move may repeat an earlier board position with the same player to move
+)
+
+//Options select the rule variations that affect which moves are legal.
+type Options struct {
+	Superko      Superko
+	AllowSuicide bool //allow moves that capture your own group
+}
+
+//OptionsForRuleset returns the options matching an OGS ruleset name, like "japanese" or "chinese".
+//Rulesets without superko, and those whose ko rules aren't covered by a superko variant (like ing),
+//only use simple ko, as do unknown rulesets.
+func OptionsForRuleset(ruleset string) Options {
+	switch strings.ToLower(ruleset) {
+	case "chinese":
+		return Options{Superko: PositionalSuperko}
+	case "aga":
+		return Options{Superko: SituationalSuperko}
+	case "nz":
+		return Options{Superko: SituationalSuperko, AllowSuicide: true}
+	case "ing":
+		return Options{AllowSuicide: true}
+	}
+	return Options{}
+}
+
+//SuperkoForAlgorithm returns the superko variant for a superko_algorithm value from OGS game data.
+//ok is false for values without a matching variant, for which the ruleset's default should be kept.
+func SuperkoForAlgorithm(algorithm string) (superko Superko, ok bool) {
+	switch algorithm {
+	case "psk":
+		return PositionalSuperko, true
+	case "ssk":
+		return SituationalSuperko, true
+	case "noresult":
+		//repetition ends the game without a result instead of forbidding the move
+		return NoSuperko, true
+	}
+	return NoSuperko, false
+}
+
+//Position is a board along with the state needed to apply moves: whose turn it is, the current ko
+//and, when superko is enabled, all earlier positions.
+type Position struct {
+	Board      *Board
+	ToMove     Color
+	Ko         *Point //point that can't be played because of simple ko, if any
+	Captures   map[Color]int
+	MoveNumber int
+	Options    Options
+	seen       map[string]colorSet //earlier board positions, with the players that were to move in them
+}
+
+//colorSet is a set of colors, as a bit mask indexed by Color.
+type colorSet uint8
+
+func (s colorSet) with(c Color) colorSet {
+	return s | 1<<c
+}
+
+func (s colorSet) has(c Color) bool {
+	return s&(1<<c) != 0
+}
+
+//NewPosition returns an empty position where black is to move.
+func NewPosition(width, height int, opts Options) *Position {
+	return FromBoard(NewBoard(width, height), Black, opts)
+}
+
+//FromBoard returns a position for an existing board. There is no ko and no history of earlier positions.
+func FromBoard(b *Board, toMove Color, opts Options) *Position {
+	p := &Position{
+		Board:    b,
+		ToMove:   toMove,
+		Captures: map[Color]int{Black: 0, White: 0},
+		Options:  opts,
+		seen:     make(map[string]colorSet),
+	}
+	p.remember()
+	return p
+}
+
+//Clone returns an independent copy of the position.
+func (p *Position) Clone() *Position {
+	clone := *p
+	clone.Board = p.Board.Clone()
+	if p.Ko != nil {
+		ko := *p.Ko
+		clone.Ko = &ko
+	}
+	clone.Captures = map[Color]int{Black: p.Captures[Black], White: p.Captures[White]}
+	clone.seen = make(map[string]colorSet, len(p.seen))
+	for k, v := range p.seen {
+		clone.seen[k] = v
+	}
+	return &clone
+}
+
+//Place puts a stone on the board without applying any rules or changing the turn,
+//which is used for handicap stones and other setup.
+func (p *Position) Place(c Color, pt Point) {
+	p.Board.Set(pt, c)
+	p.Ko = nil
+	p.remember()
+}
+
+//remember records the current board and player to move for the superko check.
+func (p *Position) remember() {
+	key := p.Board.key()
+	p.seen[key] = p.seen[key].with(p.ToMove)
+}
+
+//Legal returns nil if the player to move may play at pt, or the reason why they may not.
+func (p *Position) Legal(pt Point) error {
+	_, _, err := p.try(p.ToMove, pt)
+	return err
+}
+
+//Play plays a move for the player to move and passes the turn to the opponent.
+//It returns the captured stones, or an error if the move is illegal, in which case the position is unchanged.
+func (p *Position) Play(pt Point) ([]Point, error) {
+	return p.PlayAs(p.ToMove, pt)
+}
+
+//PlayAs plays a move for the given color, regardless of whose turn it is, and passes the turn to the opponent of c.
+//This is useful for game records in which a player moves several times in a row, e.g. for free handicap placement.
+func (p *Position) PlayAs(c Color, pt Point) ([]Point, error) {
+	board, captured, err := p.try(c, pt)
+	if err != nil {
+		return nil, err
+	}
+	p.apply(c, pt, board, captured)
+	return captured, nil
+}
+
+//Apply plays a move that is already known to be legal, e.g. because the server accepted it, for the given color.
+//Only captures are applied: ko and superko aren't checked, so a disagreement about those rules can't make
+//a game record unplayable. Suicide is always allowed. An error is only returned for points off the board.
+func (p *Position) Apply(c Color, pt Point) ([]Point, error) {
+	if pt.IsPass() {
+		p.apply(c, pt, p.Board, nil)
+		return nil, nil
+	}
+	if !p.Board.OnBoard(pt) {
+		return nil, ErrOutOfBounds
+	}
+	board, captured, _ := p.place(c, pt)
+	p.apply(c, pt, board, captured)
+	return captured, nil
+}
+
+//apply makes board the current board after c played at pt and captured the given stones.
+func (p *Position) apply(c Color, pt Point, board *Board, captured []Point) {
+	p.Ko = nil
+	if !pt.IsPass() {
+		p.Board = board
+		p.Captures[c] += len(captured)
+		//a single stone capturing a single stone, which is left with one liberty, can be retaken
+		if len(captured) == 1 {
+			group := board.Group(pt)
+			if len(group) == 1 && board.Liberties(group) == 1 {
+				ko := captured[0]
+				p.Ko = &ko
+			}
+		}
+	}
+	p.ToMove = c.Opponent()
+	p.MoveNumber++
+	//a pass leaves the board alone, but hands the turn over, which is a new situation
+	p.remember()
+}
+
+//try computes the board after c plays at pt, without changing the position.
+func (p *Position) try(c Color, pt Point) (*Board, []Point, error) {
+	if pt.IsPass() {
+		return p.Board, nil, nil
+	}
+	if !p.Board.OnBoard(pt) {
+		return nil, nil, ErrOutOfBounds
+	}
+	if p.Board.At(pt) != Empty {
+		return nil, nil, ErrOccupied
+	}
+	if p.Ko != nil && *p.Ko == pt && c == p.ToMove {
+		return nil, nil, ErrKo
+	}
+	board, captured, suicide := p.place(c, pt)
+	if suicide && !p.Options.AllowSuicide {
+		return nil, nil, ErrSuicide
+	}
+	switch seen, ok := p.seen[board.key()]; {
+	case !ok:
+	case p.Options.Superko == PositionalSuperko,
+		p.Options.Superko == SituationalSuperko && seen.has(c.Opponent()):
+		return nil, nil, ErrSuperko
+	}
+	return board, captured, nil
+}
+
+//place returns the board after c plays at pt, along with the captured stones. If the move is suicide,
+//the player's own group is removed as well, and suicide is true.
+func (p *Position) place(c Color, pt Point) (board *Board, captured []Point, suicide bool) {
+	board = p.Board.Clone()
+	board.Set(pt, c)
+	for _, n := range board.Neighbors(pt) {
+		if board.At(n) != c.Opponent() {
+			continue
+		}
+		if group := board.Group(n); board.Liberties(group) == 0 {
+			captured = append(captured, group...)
+			board.remove(group)
+		}
+	}
+	if group := board.Group(pt); board.Liberties(group) == 0 {
+		board.remove(group)
+		suicide = true
+	}
+	return board, captured, suicide
+}
@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+)
+
+//koPosition returns a 5x5 position where black can capture at (2, 1), starting a ko:
+//
+//	. B W . .
+//	B W . W .
+//	. B W . .
+//
+//The setup stones are placed while toMove is to move, which matters for situational superko.
+func koPosition(t *testing.T, opts Options, toMove Color) *Position {
+	t.Helper()
+	p := NewPosition(5, 5, opts)
+	p.ToMove = toMove
+	for _, pt := range []Point{{1, 0}, {0, 1}, {1, 2}} {
+		p.Place(Black, pt)
+	}
+	for _, pt := range []Point{{2, 0}, {1, 1}, {3, 1}, {2, 2}} {
+		p.Place(White, pt)
+	}
+	p.ToMove = Black
+	return p
+}
+
+func play(t *testing.T, p *Position, pt Point) []Point {
+	t.Helper()
+	captured, err := p.Play(pt)
+	if err != nil {
+		t.Fatalf("playing %s: %s", pt, err)
+	}
+	return captured
+}
+
+func TestKo(t *testing.T) {
+	p := koPosition(t, Options{}, Black)
+	if captured := play(t, p, Point{2, 1}); len(captured) != 1 || captured[0] != (Point{1, 1}) {
+		t.Fatalf("expected (1, 1) to be captured, got %v", captured)
+	}
+	if p.Ko == nil || *p.Ko != (Point{1, 1}) {
+		t.Fatalf("expected a ko at (1, 1), got %v", p.Ko)
+	}
+	if _, err := p.Play(Point{1, 1}); !errors.Is(err, ErrKo) {
+		t.Fatalf("expected the immediate retake to be ErrKo, got %v", err)
+	}
+	if p.ToMove != White || p.Board.At(Point{1, 1}) != Empty {
+		t.Fatal("a rejected move changed the position")
+	}
+	//a ko threat and its answer elsewhere make the retake legal again
+	play(t, p, Point{4, 4})
+	play(t, p, Point{0, 4})
+	if captured := play(t, p, Point{1, 1}); len(captured) != 1 || captured[0] != (Point{2, 1}) {
+		t.Fatalf("expected (2, 1) to be captured, got %v", captured)
+	}
+	if p.Captures[Black] != 1 || p.Captures[White] != 1 {
+		t.Errorf("expected 1 capture each, got black %d, white %d", p.Captures[Black], p.Captures[White])
+	}
+}
+
+func TestSuicide(t *testing.T) {
+	tests := []struct {
+		name   string
+		white  []Point //white stones placed before white plays at played
+		black  []Point
+		played Point
+		lost   []Point //white stones removed by the suicide
+	}{
+		{
+			name:   "single stone",
+			black:  []Point{{1, 0}, {0, 1}},
+			played: Point{0, 0},
+			lost:   []Point{{0, 0}},
+		},
+		{
+			name:   "multiple stones",
+			white:  []Point{{0, 0}},
+			black:  []Point{{2, 0}, {0, 1}, {1, 1}},
+			played: Point{1, 0},
+			lost:   []Point{{0, 0}, {1, 0}},
+		},
+	}
+	for _, tt := range tests {
+		for _, allow := range []bool{false, true} {
+			p := NewPosition(5, 5, Options{AllowSuicide: allow})
+			for _, pt := range tt.white {
+				p.Place(White, pt)
+			}
+			for _, pt := range tt.black {
+				p.Place(Black, pt)
+			}
+			p.ToMove = White
+			captured, err := p.Play(tt.played)
+			if !allow {
+				if !errors.Is(err, ErrSuicide) {
+					t.Errorf("%s: expected ErrSuicide, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s: suicide is allowed, got %v", tt.name, err)
+				continue
+			}
+			if len(captured) != 0 {
+				t.Errorf("%s: suicide captured %v", tt.name, captured)
+			}
+			for _, pt := range tt.lost {
+				if p.Board.At(pt) != Empty {
+					t.Errorf("%s: expected %s to be removed", tt.name, pt)
+				}
+			}
+			if p.Captures[Black] != 0 || p.Captures[White] != 0 {
+				t.Errorf("%s: suicide counted as a capture", tt.name)
+			}
+		}
+	}
+}
+
+func TestSuperko(t *testing.T) {
+	tests := []struct {
+		superko Superko
+		setupBy Color //player to move while the position before the capture was set up
+		want    error
+	}{
+		{NoSuperko, Black, nil},
+		{PositionalSuperko, Black, ErrSuperko},
+		{PositionalSuperko, White, ErrSuperko},
+		{SituationalSuperko, Black, ErrSuperko},
+		{SituationalSuperko, White, nil},
+	}
+	for _, tt := range tests {
+		p := koPosition(t, Options{Superko: tt.superko}, tt.setupBy)
+		play(t, p, Point{2, 1})
+		//retaking repeats the position before the capture, with black to move again;
+		//drop the simple ko to see what superko makes of that
+		p.Ko = nil
+		if _, err := p.Play(Point{1, 1}); !errors.Is(err, tt.want) {
+			t.Errorf("superko %d, set up with %s to move: expected %v, got %v", tt.superko, tt.setupBy, tt.want, err)
+		}
+	}
+}
+
+func TestSuperkoAfterPasses(t *testing.T) {
+	//set up with white to move, so only the passes put the position before the capture on record with black to move
+	p := koPosition(t, Options{Superko: SituationalSuperko}, White)
+	play(t, p, Pass)
+	play(t, p, Pass)
+	play(t, p, Point{2, 1})
+	p.Ko = nil
+	if _, err := p.Play(Point{1, 1}); !errors.Is(err, ErrSuperko) {
+		t.Errorf("expected the retake to repeat the situation after the passes, got %v", err)
+	}
+}
+
+func TestApplyIgnoresKo(t *testing.T) {
+	p := koPosition(t, Options{Superko: PositionalSuperko}, Black)
+	play(t, p, Point{2, 1})
+	captured, err := p.Apply(White, Point{1, 1})
+	if err != nil || len(captured) != 1 {
+		t.Fatalf("expected Apply to retake the ko, got %v, %v", captured, err)
+	}
+	if p.Captures[White] != 1 || p.ToMove != Black {
+		t.Errorf("unexpected position after Apply: %d captures, %s to move", p.Captures[White], p.ToMove)
+	}
+	if _, err := p.Apply(Black, Point{5, 5}); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("expected ErrOutOfBounds, got %v", err)
+	}
+}
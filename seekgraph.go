@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//The seek graph page lists the open challenges of other players as OGS announces them, and accepting one
+//opens its game. From there, automatch can also be asked to find an opponent.
+
+var seekList *tview.List
+var seekFrame *tview.Frame
+var seekGraph *api.SeekGraph         //nil while the page is closed
+var seekEntries []api.SeekGraphEntry //challenges shown in seekList, in the same order
+var automatchForm *tview.Form
+var automatchFrame *tview.Frame
+
+var automatchSpeeds = []string{"live", "blitz", "correspondence"}
+
+const seekHint = "Return: accept, m: automatch, q: back"
+const automatchHint = "Find an opponent with similar rank. Esc: back"
+
+func newSeekGraphPage() *tview.Frame {
+	seekList = tview.NewList()
+	seekFrame = tview.NewFrame(seekList)
+	seekFrame.SetBorders(0, 0, 0, 0, 0, 0)
+	seekList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			return event
+		}
+		switch event.Rune() {
+		case 'q':
+			closeSeekGraph()
+			rootPage.SwitchToPage("browser")
+		case 'm':
+			rootPage.SwitchToPage("automatch")
+		default:
+			return event
+		}
+		return nil
+	})
+	return seekFrame
+}
+
+func newAutomatchPage() *tview.Frame {
+	automatchForm = tview.NewForm()
+	automatchForm.
+		AddCheckbox("19x19", true, nil).
+		AddCheckbox("13x13", false, nil).
+		AddCheckbox("9x9", false, nil).
+		AddDropDown("Speed", automatchSpeeds, 0, nil).
+		AddButton("Search", findMatch).
+		AddButton("Cancel", func() {
+			rootPage.SwitchToPage("seekgraph")
+		}).
+		SetCancelFunc(func() {
+			rootPage.SwitchToPage("seekgraph")
+		})
+	automatchFrame = tview.NewFrame(automatchForm)
+	automatchFrame.SetBorders(0, 0, 0, 0, 1, 0)
+	setAutomatchStatus("", tcell.ColorDefault)
+	return automatchFrame
+}
+
+//openSeekGraph subscribes to the seek graph and shows the seek graph page.
+func openSeekGraph() {
+	seekList.Clear()
+	seekEntries = nil
+	setSeekStatus("", tcell.ColorDefault)
+	async(func(ctx context.Context) {
+		realtimeClient, err := ogs.Realtime(ctx)
+		if err != nil {
+			showError(err)
+			return
+		}
+		closeSeekGraph()
+		seekGraph, err = realtimeClient.SeekGraph(ctx, func(entries []api.SeekGraphEntry) {
+			app.QueueUpdateDraw(func() {
+				updateSeekList(entries)
+			})
+		})
+		if err != nil {
+			showError(err)
+			return
+		}
+		rootPage.SwitchToPage("seekgraph")
+	})
+}
+
+//closeSeekGraph stops updating the seek graph page.
+func closeSeekGraph() {
+	if seekGraph != nil {
+		seekGraph.Close()
+		seekGraph = nil
+	}
+}
+
+//updateSeekList replaces the challenges on the seek graph page, leaving out the user's own.
+//The selection stays on the same challenge if it is still open.
+func updateSeekList(entries []api.SeekGraphEntry) {
+	var selected int64
+	if i := seekList.GetCurrentItem(); i >= 0 && i < len(seekEntries) {
+		selected = seekEntries[i].ChallengeID
+	}
+	seekList.Clear()
+	seekEntries = nil
+	for _, e := range entries {
+		if e.UserID == ogs.AuthData.Player.ID {
+			continue
+		}
+		i := len(seekEntries)
+		seekEntries = append(seekEntries, e)
+		seekList.AddItem(e.Name, e.Description(), 0, func() {
+			acceptOpenChallenge(i)
+		})
+		if e.ChallengeID == selected {
+			seekList.SetCurrentItem(i)
+		}
+	}
+	status := ""
+	if len(seekEntries) == 0 {
+		status = "There are no open challenges right now."
+	}
+	setSeekStatus(status, tcell.ColorDefault)
+}
+
+//setSeekStatus shows text above the seek graph list.
+func setSeekStatus(text string, color tcell.Color) {
+	seekFrame.Clear().
+		AddText(fmt.Sprintf("Open challenges (%d)", len(seekEntries)), true, tview.AlignLeft, tcell.PaletteColor(3)).
+		AddText(seekHint, false, tview.AlignLeft, tcell.ColorDefault)
+	if text != "" {
+		seekFrame.AddText(text, true, tview.AlignLeft, color)
+	}
+}
+
+//acceptOpenChallenge accepts the i-th challenge on the seek graph and opens its game.
+func acceptOpenChallenge(i int) {
+	if i < 0 || i >= len(seekEntries) {
+		return
+	}
+	e := seekEntries[i]
+	async(func(ctx context.Context) {
+		if err := ogs.AcceptOpenChallenge(ctx, e.ChallengeID); err != nil {
+			setSeekStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		closeSeekGraph()
+		if err := enterGame(ctx, e.GameID, "browser"); err != nil {
+			showError(err)
+		}
+	})
+}
+
+//setAutomatchStatus shows text above the automatch form.
+func setAutomatchStatus(text string, color tcell.Color) {
+	automatchFrame.Clear().
+		AddText("Automatch", true, tview.AlignLeft, tcell.PaletteColor(3)).
+		AddText(automatchHint, false, tview.AlignLeft, tcell.ColorDefault)
+	if text != "" {
+		automatchFrame.AddText(text, true, tview.AlignLeft, color)
+	}
+}
+
+//findMatch asks automatch for an opponent with the settings from the form, and opens the game once one is found.
+//The search is cancelled with Esc on the loading screen.
+func findMatch() {
+	var req api.AutomatchRequest
+	for _, size := range []int{19, 13, 9} {
+		if automatchForm.GetFormItemByLabel(fmt.Sprintf("%dx%d", size, size)).(*tview.Checkbox).IsChecked() {
+			req.Sizes = append(req.Sizes, size)
+		}
+	}
+	if len(req.Sizes) == 0 {
+		setAutomatchStatus("Pick at least one board size.", tcell.PaletteColor(1))
+		return
+	}
+	_, req.Speed = automatchForm.GetFormItemByLabel("Speed").(*tview.DropDown).GetCurrentOption()
+	setAutomatchStatus("", tcell.ColorDefault)
+	async(func(ctx context.Context) {
+		setLoadingText("Searching for an opponent...\n\nEsc: cancel")
+		defer setLoadingText(loadingText)
+		realtimeClient, err := ogs.Realtime(ctx)
+		if err != nil {
+			setAutomatchStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		found := make(chan int64, 1)
+		match, err := realtimeClient.FindMatch(ctx, req, func(gameID int64) {
+			found <- gameID
+		})
+		if err != nil {
+			setAutomatchStatus(err.Error(), tcell.PaletteColor(1))
+			return
+		}
+		select {
+		case gameID := <-found:
+			closeSeekGraph()
+			if err := enterGame(ctx, gameID, "browser"); err != nil {
+				showError(err)
+			}
+		case <-ctx.Done():
+			match.Cancel()
+			setAutomatchStatus("Search cancelled.", tcell.ColorDefault)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package sgf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+//SyntaxError is returned by Parse for input that isn't valid SGF.
+type SyntaxError struct {
+	Line   int //counting from 1
+	Column int //in bytes, counting from 1
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("SGF syntax error at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+//Parse reads an SGF collection and returns the root node of each game tree in it.
+//Anything before the first game tree is ignored, as some programs put headers there.
+func Parse(r io.Reader) ([]*Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseString(string(data))
+}
+
+//ParseString is Parse for SGF in a string.
+func ParseString(s string) ([]*Node, error) {
+	p := &parser{s: s}
+	start := strings.IndexByte(s, '(')
+	if start < 0 {
+		return nil, p.errorf("no game tree found")
+	}
+	p.pos = start
+	var roots []*Node
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return roots, nil
+		}
+		if p.s[p.pos] != '(' {
+			return nil, p.errorf("expected '(' but found %q", p.s[p.pos])
+		}
+		root, err := p.tree(nil)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+//tree parses a parenthesized game tree and adds it as a child of parent, if there is one.
+//It returns the first node of the tree.
+func (p *parser) tree(parent *Node) (*Node, error) {
+	p.pos++ //the '('
+	var first *Node
+	p.skipSpace()
+	if p.pos >= len(p.s) || p.s[p.pos] != ';' {
+		return nil, p.errorf("a game tree must start with a node")
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("unexpected end of input, missing ')'")
+		}
+		switch p.s[p.pos] {
+		case ';':
+			p.pos++
+			var n *Node
+			if parent == nil {
+				n = &Node{}
+			} else {
+				n = parent.AddChild()
+			}
+			if err := p.properties(n); err != nil {
+				return nil, err
+			}
+			if first == nil {
+				first = n
+			}
+			parent = n
+		case '(':
+			if _, err := p.tree(parent); err != nil {
+				return nil, err
+			}
+		case ')':
+			p.pos++
+			return first, nil
+		default:
+			return nil, p.errorf("unexpected %q", p.s[p.pos])
+		}
+	}
+}
+
+//properties parses the properties of a node.
+func (p *parser) properties(n *Node) error {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) || !isLetter(p.s[p.pos]) {
+			return nil
+		}
+		var id strings.Builder
+		for p.pos < len(p.s) && isLetter(p.s[p.pos]) {
+			//lowercase letters were allowed in old versions of SGF, e.g. AddBlack for AB
+			if c := p.s[p.pos]; c >= 'A' && c <= 'Z' {
+				id.WriteByte(c)
+			}
+			p.pos++
+		}
+		if id.Len() == 0 {
+			return p.errorf("property identifier without uppercase letters")
+		}
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != '[' {
+			return p.errorf("property %s has no value", id.String())
+		}
+		var values []string
+		for p.pos < len(p.s) && p.s[p.pos] == '[' {
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+			p.skipSpace()
+		}
+		n.Add(id.String(), values...)
+	}
+}
+
+//value parses a bracketed property value and returns it unescaped. Escaped line breaks are removed,
+//as SGF uses them to wrap long lines.
+func (p *parser) value() (string, error) {
+	start := p.pos
+	p.pos++ //the '['
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case ']':
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.s) {
+				break
+			}
+			c = p.s[p.pos]
+			p.pos++
+			if c == '\r' || c == '\n' {
+				//a soft line break, which may be \r\n or \n\r
+				if p.pos < len(p.s) && (p.s[p.pos] == '\r' || p.s[p.pos] == '\n') && p.s[p.pos] != c {
+					p.pos++
+				}
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	p.pos = start
+	return "", p.errorf("unterminated property value")
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n\v\f", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+//errorf returns a SyntaxError for the current position.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	pos := p.pos
+	if pos > len(p.s) {
+		pos = len(p.s)
+	}
+	before := p.s[:pos]
+	line := strings.Count(before, "\n") + 1
+	column := pos - strings.LastIndexByte(before, '\n')
+	return &SyntaxError{Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,141 @@
+package sgf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+//roundTrip parses s, writes it back with String and parses the result again, failing if anything changed.
+func roundTrip(t *testing.T, s string) *Node {
+	t.Helper()
+	roots, err := ParseString(s)
+	if err != nil {
+		t.Fatalf("parsing %q: %s", s, err)
+	}
+	if len(roots) != 1 {
+		t.Fatalf("expected 1 game tree, got %d", len(roots))
+	}
+	written := roots[0].String()
+	again, err := ParseString(written)
+	if err != nil {
+		t.Fatalf("parsing written SGF %q: %s", written, err)
+	}
+	if !sameTree(roots[0], again[0]) {
+		t.Fatalf("round trip changed the tree:\n%s\n%s", written, again[0].String())
+	}
+	if again[0].String() != written {
+		t.Fatalf("writing is not stable:\n%s\n%s", written, again[0].String())
+	}
+	return roots[0]
+}
+
+//sameTree compares the properties and children of two trees.
+func sameTree(a, b *Node) bool {
+	if !reflect.DeepEqual(a.Properties, b.Properties) || len(a.Children) != len(b.Children) {
+		return false
+	}
+	for i := range a.Children {
+		if a.Children[i].Parent != a || b.Children[i].Parent != b || !sameTree(a.Children[i], b.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoundTripVariations(t *testing.T) {
+	root := roundTrip(t, "(;FF[4]GM[1]SZ[9];B[cc];W[gg](;B[ee];W[ff])(;B[ff](;W[ee])(;W[dd]))(;B[dd]))")
+	n := root.Children[0].Children[0]
+	if len(n.Children) != 3 {
+		t.Fatalf("expected 3 variations, got %d", len(n.Children))
+	}
+	if got := n.Children[1].Children[1].Get("W"); got != "dd" {
+		t.Errorf("expected the second variation of the second variation to be W[dd], got %q", got)
+	}
+}
+
+func TestRoundTripEscapes(t *testing.T) {
+	root := roundTrip(t, "(;C[a \\] b \\\\ c \\: d]GN[x\\\ny];C[\\]\\\\])")
+	if got, want := root.Get("C"), `a ] b \ c : d`; got != want {
+		t.Errorf("expected comment %q, got %q", want, got)
+	}
+	//an escaped line break is a soft break and disappears
+	if got := root.Get("GN"); got != "xy" {
+		t.Errorf("expected GN[xy], got %q", got)
+	}
+	if got, want := root.Children[0].Get("C"), `]\`; got != want {
+		t.Errorf("expected comment %q, got %q", want, got)
+	}
+}
+
+func TestPasses(t *testing.T) {
+	for _, tt := range []struct {
+		sgf  string
+		pass bool
+	}{
+		{"(;SZ[19];B[])", true},
+		{"(;SZ[19];B[tt])", true},
+		{"(;SZ[9];W[tt])", true},
+		{"(;SZ[21];B[tt])", false},
+		{"(;GM[1];B[pd])", false},
+	} {
+		root := roundTrip(t, tt.sgf)
+		m, err := root.Children[0].Move()
+		if err != nil || m == nil {
+			t.Errorf("%s: expected a move, got %v, %v", tt.sgf, m, err)
+			continue
+		}
+		if m.IsPass() != tt.pass {
+			t.Errorf("%s: expected pass to be %t, got move %+v", tt.sgf, tt.pass, *m)
+		}
+	}
+}
+
+func TestCompressedPoints(t *testing.T) {
+	root := roundTrip(t, "(;AB[aa:bc][dd]AW[ee:ee]TR[ab:ba])")
+	black, err := root.Points("AB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}, {1, 2}, {3, 3}}
+	if !reflect.DeepEqual(black, want) {
+		t.Errorf("expected %v, got %v", want, black)
+	}
+	if white, err := root.Points("AW"); err != nil || !reflect.DeepEqual(white, []Point{{4, 4}}) {
+		t.Errorf("expected [{4 4}], got %v, %v", white, err)
+	}
+	//the corners of a rectangle must be given upper left first
+	if _, err := root.Points("TR"); err == nil {
+		t.Error("expected an error for a reversed rectangle")
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	for _, s := range []string{
+		"(;B[aa",          //unterminated value
+		"(;B[aa];W[bb]",   //missing )
+		"(;B[aa](;W[bb])", //missing ) after a variation
+		"(;B[aa])x",       //junk after a tree
+		"(;B[aa]))",       //unbalanced )
+		"(B[aa])",         //no node
+		"(;B)",            //no value
+		"no tree",
+	} {
+		_, err := ParseString(s)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected a SyntaxError, got %v", s, err)
+		}
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	_, err := ParseString("(;GM[1]\n;B[aa]\n;W[bb)")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected a SyntaxError, got %v", err)
+	}
+	if syntaxErr.Line != 3 || syntaxErr.Column != 3 {
+		t.Errorf("expected the error at the start of the unterminated value (line 3, column 3), got line %d, column %d", syntaxErr.Line, syntaxErr.Column)
+	}
+}
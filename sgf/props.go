@@ -0,0 +1,181 @@
+package sgf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Point is a point on the board, counting from 0 at the upper left corner.
+type Point struct {
+	X, Y int
+}
+
+//Move is the move of a node. X and Y are -1 for a pass.
+type Move struct {
+	Black bool
+	X, Y  int
+}
+
+//IsPass returns true if the move is a pass.
+func (m Move) IsPass() bool {
+	return m.X < 0 || m.Y < 0
+}
+
+//Label is a text shown on the board at a point, from the LB property.
+type Label struct {
+	Point
+	Text string
+}
+
+//EncodePoint returns the SGF coordinates of a point, where "aa" is the upper left corner and
+//the letters a-z and then A-Z count up from 0. A negative x or y is a pass, which FF[4] writes as an empty value.
+func EncodePoint(x, y int) string {
+	if x < 0 || y < 0 {
+		return ""
+	}
+	return string([]byte{coordLetter(x), coordLetter(y)})
+}
+
+func coordLetter(i int) byte {
+	if i < 26 {
+		return byte('a' + i)
+	}
+	return byte('A' + i - 26)
+}
+
+//ParsePoint reads SGF coordinates as written by EncodePoint. Passes aren't accepted, see Node.Move for those.
+func ParsePoint(s string) (Point, error) {
+	if len(s) != 2 {
+		return Point{}, fmt.Errorf("invalid SGF point: %q", s)
+	}
+	x, err := coordIndex(s[0])
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := coordIndex(s[1])
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+//coordIndex converts a coordinate letter to a number: a-z are 0-25 and A-Z are 26-51.
+func coordIndex(c byte) (int, error) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), nil
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 26, nil
+	}
+	return 0, fmt.Errorf("invalid SGF coordinate: %q", c)
+}
+
+//ParsePoints reads a list of points, as used by e.g. AB and TR. Values may also be compressed
+//to a rectangle of points, like "aa:cc" for the 9 points from aa to cc.
+func ParsePoints(values []string) ([]Point, error) {
+	var points []Point
+	for _, v := range values {
+		from, to, compressed := strings.Cut(v, ":")
+		p1, err := ParsePoint(from)
+		if err != nil {
+			return nil, err
+		}
+		if !compressed {
+			points = append(points, p1)
+			continue
+		}
+		p2, err := ParsePoint(to)
+		if err != nil {
+			return nil, err
+		}
+		if p1.X > p2.X || p1.Y > p2.Y {
+			return nil, fmt.Errorf("invalid SGF point rectangle: %q", v)
+		}
+		for y := p1.Y; y <= p2.Y; y++ {
+			for x := p1.X; x <= p2.X; x++ {
+				points = append(points, Point{X: x, Y: y})
+			}
+		}
+	}
+	return points, nil
+}
+
+//Root returns the root node of the game tree n is in.
+func (n *Node) Root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+//Size returns the board size from the SZ property of the root node, which defaults to 19x19.
+func (n *Node) Size() (width, height int, err error) {
+	sz := n.Root().Get("SZ")
+	if sz == "" {
+		return 19, 19, nil
+	}
+	w, h, rect := strings.Cut(sz, ":")
+	if width, err = strconv.Atoi(w); err != nil {
+		return 0, 0, fmt.Errorf("invalid SGF board size: %q", sz)
+	}
+	height = width
+	if rect {
+		if height, err = strconv.Atoi(h); err != nil {
+			return 0, 0, fmt.Errorf("invalid SGF board size: %q", sz)
+		}
+	}
+	if width < 1 || height < 1 || width > 52 || height > 52 {
+		return 0, 0, fmt.Errorf("invalid SGF board size: %q", sz)
+	}
+	return width, height, nil
+}
+
+//Move returns the move played in n, or nil if there is none. Both an empty value and, on boards up to 19x19,
+//"tt" are read as a pass.
+func (n *Node) Move() (*Move, error) {
+	for _, color := range []string{"B", "W"} {
+		if !n.Has(color) {
+			continue
+		}
+		m := &Move{Black: color == "B", X: -1, Y: -1}
+		v := n.Get(color)
+		if v == "" {
+			return m, nil
+		}
+		if v == "tt" {
+			if width, height, err := n.Size(); err == nil && width <= 19 && height <= 19 {
+				return m, nil
+			}
+		}
+		p, err := ParsePoint(v)
+		if err != nil {
+			return nil, err
+		}
+		m.X, m.Y = p.X, p.Y
+		return m, nil
+	}
+	return nil, nil
+}
+
+//Points returns the points of a property holding a list of points, like AB, AW, AE, TR, SQ, CR and MA.
+func (n *Node) Points(id string) ([]Point, error) {
+	return ParsePoints(n.Values(id))
+}
+
+//Labels returns the labels of the LB property.
+func (n *Node) Labels() ([]Label, error) {
+	var labels []Label
+	for _, v := range n.Values("LB") {
+		point, text, ok := strings.Cut(v, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid SGF label: %q", v)
+		}
+		p, err := ParsePoint(point)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, Label{Point: p, Text: text})
+	}
+	return labels, nil
+}
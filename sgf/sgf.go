@@ -0,0 +1,136 @@
+//Package sgf reads and writes game records in the Smart Game Format, FF[4], as described at https://www.red-bean.com/sgf/.
+//Only what is needed for go (GM[1]) is supported.
+package sgf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+//Node is a node of an SGF game tree. The root node holds the game information and setup stones,
+//the nodes after it usually hold a single move each.
+type Node struct {
+	Properties []Property
+	Children   []*Node //the first child continues the main line, the others are variations
+	Parent     *Node   //nil for the root node
+}
+
+//Property is a single property of a node, like B[pd] or AB[dd][pp]. Values are stored unescaped.
+type Property struct {
+	ID     string
+	Values []string
+}
+
+//AddChild appends a new node to the children of n and returns it.
+func (n *Node) AddChild() *Node {
+	child := &Node{Parent: n}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+//Get returns the first value of a property, or an empty string if n doesn't have it.
+func (n *Node) Get(id string) string {
+	if values := n.Values(id); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+//Values returns all values of a property, or nil if n doesn't have it.
+func (n *Node) Values(id string) []string {
+	for _, p := range n.Properties {
+		if p.ID == id {
+			return p.Values
+		}
+	}
+	return nil
+}
+
+//Has returns true if n has the property, even if its value is empty.
+func (n *Node) Has(id string) bool {
+	for _, p := range n.Properties {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+//Set replaces the values of a property, adding it if n doesn't have it yet.
+func (n *Node) Set(id string, values ...string) {
+	for i, p := range n.Properties {
+		if p.ID == id {
+			n.Properties[i].Values = values
+			return
+		}
+	}
+	n.Properties = append(n.Properties, Property{ID: id, Values: values})
+}
+
+//Add appends values to a property, adding it if n doesn't have it yet.
+func (n *Node) Add(id string, values ...string) {
+	for i, p := range n.Properties {
+		if p.ID == id {
+			n.Properties[i].Values = append(n.Properties[i].Values, values...)
+			return
+		}
+	}
+	n.Set(id, values...)
+}
+
+//String returns the game tree rooted at n in SGF notation.
+func (n *Node) String() string {
+	var sb strings.Builder
+	writeTree(&sb, n)
+	return sb.String()
+}
+
+//Write writes the game trees rooted at the given nodes to w as a single SGF collection.
+func Write(w io.Writer, roots ...*Node) error {
+	var sb strings.Builder
+	for _, root := range roots {
+		writeTree(&sb, root)
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+//writeTree writes n and its descendants as a parenthesized game tree. The nodes of a sequence are written
+//one per line, and variations start on a line of their own.
+func writeTree(sb *strings.Builder, n *Node) {
+	sb.WriteByte('(')
+	for {
+		writeNode(sb, n)
+		if len(n.Children) != 1 {
+			break
+		}
+		sb.WriteByte('\n')
+		n = n.Children[0]
+	}
+	for _, child := range n.Children {
+		sb.WriteByte('\n')
+		writeTree(sb, child)
+	}
+	sb.WriteByte(')')
+}
+
+func writeNode(sb *strings.Builder, n *Node) {
+	sb.WriteByte(';')
+	for _, p := range n.Properties {
+		sb.WriteString(p.ID)
+		if len(p.Values) == 0 {
+			//a property needs at least one value
+			sb.WriteString("[]")
+		}
+		for _, v := range p.Values {
+			fmt.Fprintf(sb, "[%s]", escape(v))
+		}
+	}
+}
+
+//escape prefixes the characters that end or escape a value with a backslash.
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `]`, `\]`).Replace(v)
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//GameChat shows the chat of a game, with an input line below it to write messages.
+//Tab switches the input line between the channels that may be written to, PgUp/PgDn scroll the chat.
+type GameChat struct {
+	*tview.Flex
+	Input    *tview.InputField
+	messages *tview.TextView
+	mu       sync.Mutex
+	seen     map[string]bool //chat IDs of messages already shown; OGS resends the history on every connect
+	channels []api.ChatChannel
+	channel  int //index into channels
+	lines    []api.OnChatResult
+}
+
+func NewGameChat() *GameChat {
+	c := &GameChat{
+		Flex:     tview.NewFlex().SetDirection(tview.FlexRow),
+		Input:    tview.NewInputField(),
+		messages: tview.NewTextView(),
+		seen:     make(map[string]bool),
+	}
+	c.messages.SetDynamicColors(true).SetScrollable(true).SetWrap(true)
+	c.Input.SetFieldBackgroundColor(tcell.ColorDefault)
+	c.Input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			c.toggleChannel()
+			return nil
+		case tcell.KeyPgUp, tcell.KeyPgDn:
+			c.messages.InputHandler()(event, func(tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+	c.Flex.
+		AddItem(c.messages, 0, 1, false).
+		AddItem(c.Input, 1, 0, false)
+	c.Flex.SetBorder(true).SetTitle("Chat")
+	c.Clear(api.ChatMain)
+	return c
+}
+
+//Channel returns the channel messages from the input line should be sent to.
+func (c *GameChat) Channel() api.ChatChannel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.channels[c.channel]
+}
+
+func (c *GameChat) toggleChannel() {
+	c.mu.Lock()
+	c.channel = (c.channel + 1) % len(c.channels)
+	c.mu.Unlock()
+	c.Input.SetLabel(fmt.Sprintf("%s> ", c.Channel()))
+}
+
+//Clear removes all messages, to start showing the chat of another game.
+//The input line writes to the first of the given channels, and Tab cycles through the others.
+func (c *GameChat) Clear(channels ...api.ChatChannel) {
+	c.mu.Lock()
+	c.seen = make(map[string]bool)
+	c.lines = nil
+	c.channels = channels
+	c.channel = 0
+	c.mu.Unlock()
+	c.messages.Clear()
+	c.Input.SetText("")
+	c.Input.SetLabel(fmt.Sprintf("%s> ", channels[0]))
+}
+
+//Add shows a chat message, unless it was shown before.
+func (c *GameChat) Add(r api.OnChatResult) {
+	c.mu.Lock()
+	if r.Line.ChatID != "" {
+		if c.seen[r.Line.ChatID] {
+			c.mu.Unlock()
+			return
+		}
+		c.seen[r.Line.ChatID] = true
+	}
+	c.lines = append(c.lines, r)
+	c.mu.Unlock()
+	var channel string
+	if r.Channel != api.ChatMain {
+		channel = fmt.Sprintf("[gray](%s)[-] ", r.Channel)
+	}
+	fmt.Fprintf(c.messages, "[gray]%d[-] %s[yellow]%s[-]: %s\n",
+		r.Line.MoveNumber, channel, tview.Escape(r.Line.Username), tview.Escape(string(r.Line.Body)))
+	c.messages.ScrollToEnd()
+}
+
+//Lines returns all messages shown, in the order they were received.
+func (c *GameChat) Lines() []api.OnChatResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]api.OnChatResult(nil), c.lines...)
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lvank/termsuji/api"
+	"github.com/rivo/tview"
+)
+
+//lowTime is the time left at which a clock is highlighted.
+const lowTime = 30 * time.Second
+
+//GameClock shows the clocks of both players. Between clock events from OGS, it counts down locally once a second.
+type GameClock struct {
+	*tview.TextView
+	app      *tview.Application
+	mu       sync.Mutex
+	clock    *api.OnClockResult
+	offset   time.Duration //server time minus local time
+	system   string
+	black    api.Player
+	white    api.Player
+	finished bool
+	stop     chan struct{}
+}
+
+func NewGameClock(app *tview.Application) *GameClock {
+	c := &GameClock{
+		TextView: tview.NewTextView(),
+		app:      app,
+	}
+	c.SetDynamicColors(true).SetBorder(true).SetTitle("Clock")
+	return c
+}
+
+//Start shows the clocks for a game with the given time control and players, and starts counting down.
+func (c *GameClock) Start(tc api.TimeControl, black, white api.Player) {
+	c.Stop()
+	c.mu.Lock()
+	c.clock = nil
+	c.system = tc.System
+	c.black, c.white = black, white
+	c.finished = false
+	stop := make(chan struct{})
+	c.stop = stop
+	c.mu.Unlock()
+	c.render()
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.app.QueueUpdateDraw(c.render)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+//Update replaces the clock with a newer one from OGS.
+func (c *GameClock) Update(clock api.OnClockResult) {
+	c.mu.Lock()
+	c.clock = &clock
+	c.offset = clock.ServerOffset(time.Now())
+	c.mu.Unlock()
+	c.render()
+}
+
+//SetFinished stops the clocks from counting down, keeping the last times visible.
+func (c *GameClock) SetFinished() {
+	c.mu.Lock()
+	c.finished = true
+	c.mu.Unlock()
+	c.render()
+}
+
+//Stop stops counting down.
+func (c *GameClock) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+func (c *GameClock) render() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clock == nil {
+		c.SetText("")
+		return
+	}
+	black, white := c.clock.BlackTime, c.clock.WhiteTime
+	if !c.finished {
+		black, white = c.clock.TimeLeft(c.system, time.Now(), c.offset)
+	}
+	paused := ""
+	if c.clock.PausedSince != 0 {
+		paused = "\n[yellow]Paused[-]"
+	}
+	c.SetText(fmt.Sprintf("%s\n%s%s",
+		c.line("Black", c.black, black, c.clock.CurrentPlayerID == c.clock.BlackPlayerID),
+		c.line("White", c.white, white, c.clock.CurrentPlayerID == c.clock.WhitePlayerID),
+		paused))
+}
+
+//line formats the clock of a single player. The player to move is marked, and low time is shown in red.
+func (c *GameClock) line(color string, player api.Player, clock api.PlayerClock, toMove bool) string {
+	marker := "  "
+	if toMove && !c.finished {
+		marker = "> "
+	}
+	timeColor := "-"
+	if clock.Remaining(c.system) < lowTime && c.system != "none" {
+		timeColor = "red"
+	}
+	return fmt.Sprintf("%s%s %s: [%s]%s[-]", marker, color, tview.Escape(player.Username), timeColor, clock.Format(c.system))
+}
@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lvank/termsuji/api"
+	"github.com/lvank/termsuji/rules"
+)
+
+//errMoveGap is returned by applyMove when a move event doesn't follow the last known move.
+var errMoveGap = errors.New("missed a move")
+
+//errReviewing is shown when trying to change the game while an earlier move is shown.
+var errReviewing = errors.New("You are viewing an earlier move; press End to return to the game")
+
+//loadGameData replaces the local position with the one described by a gamedata event.
+func (g *GoBoardUI) loadGameData(gamedata *api.GameData) error {
+	g.mu.Lock()
+	g.phase, g.outcome = gamedata.Phase, gamedata.Outcome
+	g.undoRequested = gamedata.UndoRequested
+	g.undoRequestedByMe = gamedata.UndoRequested > 0 && moveColor(gamedata, gamedata.UndoRequested-1) == g.playerColor()
+	g.mu.Unlock()
+	if err := g.setGameData(gamedata); err != nil {
+		return err
+	}
+	return g.setRemoved(gamedata.Removed)
+}
+
+//setRemoved replaces the set of stones marked dead with the given SGF coordinates.
+func (g *GoBoardUI) setRemoved(stones string) error {
+	points, err := api.ConvertSGFCoords(stones)
+	if err != nil {
+		return err
+	}
+	removed := make(map[rules.Point]bool, len(points))
+	for _, p := range points {
+		removed[rules.Point{X: p.X, Y: p.Y}] = true
+	}
+	g.mu.Lock()
+	g.removed = removed
+	g.mu.Unlock()
+	g.updateBoardState()
+	return nil
+}
+
+//removedStones returns all stones currently marked dead.
+func (g *GoBoardUI) removedStones() []api.BoardPos {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stones := make([]api.BoardPos, 0, len(g.removed))
+	for p := range g.removed {
+		stones = append(stones, api.BoardPos{X: p.X, Y: p.Y})
+	}
+	sort.Slice(stones, func(i, j int) bool {
+		if stones[i].Y != stones[j].Y {
+			return stones[i].Y < stones[j].Y
+		}
+		return stones[i].X < stones[j].X
+	})
+	return stones
+}
+
+//setGameData rebuilds the local position from a game record.
+func (g *GoBoardUI) setGameData(gamedata *api.GameData) error {
+	pos, err := positionFromGameData(gamedata)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.gamedata = gamedata
+	g.position = pos
+	g.moves = append([]api.Move(nil), gamedata.Moves...)
+	g.lastTurnPass = len(gamedata.Moves) > 0 && gamedata.Moves[len(gamedata.Moves)-1].IsPass()
+	g.mu.Unlock()
+	g.updateBoardState()
+	return nil
+}
+
+//resync downloads the game record through the REST API and rebuilds the position from it.
+//This is only needed when the local position can't be trusted anymore, e.g. after missing a move event.
+func (g *GoBoardUI) resync() {
+	gamedata, err := g.client.GetGameData(g.ctx, g.gameID)
+	if err == nil {
+		err = g.setGameData(gamedata)
+	}
+	g.setErr(err)
+}
+
+//applyMove plays a move from a realtime event on the local position. Moves that are already known are ignored;
+//an error is returned if moves were missed, in which case a resync is needed. OGS already accepted the move,
+//so it isn't checked against the local rules.
+func (g *GoBoardUI) applyMove(m api.OnMoveResult) error {
+	g.mu.Lock()
+	if g.position == nil {
+		g.mu.Unlock()
+		return errMoveGap
+	}
+	switch {
+	case m.MoveNumber <= len(g.moves):
+		g.mu.Unlock()
+		return nil
+	case m.MoveNumber > len(g.moves)+1:
+		g.mu.Unlock()
+		return errMoveGap
+	}
+	_, err := g.position.Apply(moveColor(g.gamedata, len(g.moves)), rules.Point{X: m.Move.X, Y: m.Move.Y})
+	if err == nil {
+		g.moves = append(g.moves, api.Move{X: m.Move.X, Y: m.Move.Y})
+		g.lastTurnPass = m.Move.X == -1 && m.Move.Y == -1
+		//not necessarily the opponent, e.g. during free handicap placement
+		g.position.ToMove = moveColor(g.gamedata, len(g.moves))
+	}
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	g.updateBoardState()
+	return nil
+}
+
+//undo takes back the last move after the undo request made at moveNumber was accepted.
+//An error is returned if the local position doesn't match the request, in which case a resync is needed.
+func (g *GoBoardUI) undo(moveNumber int) error {
+	g.mu.Lock()
+	if g.position == nil || moveNumber != len(g.moves) || moveNumber == 0 {
+		g.mu.Unlock()
+		return errMoveGap
+	}
+	gamedata := *g.gamedata
+	gamedata.Moves = g.moves[:moveNumber-1]
+	pos, err := positionFromGameData(&gamedata)
+	if err == nil {
+		g.position = pos
+		g.moves = gamedata.Moves
+		g.lastTurnPass = len(gamedata.Moves) > 0 && gamedata.Moves[len(gamedata.Moves)-1].IsPass()
+	}
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	g.updateBoardState()
+	return nil
+}
+
+//legal checks whether the logged in user may play at pt in the current position.
+func (g *GoBoardUI) legal(pt rules.Point) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.position == nil {
+		return nil
+	}
+	if g.position.ToMove != g.playerColor() {
+		return errors.New("It is not your turn")
+	}
+	return g.position.Legal(pt)
+}
+
+//updateBoardState replaces BoardState with one derived from the local position, which is what gets drawn.
+//While reviewing, the position at the reviewed move is drawn instead.
+func (g *GoBoardUI) updateBoardState() {
+	g.mu.Lock()
+	if g.position == nil {
+		//nothing was loaded yet, or loading failed
+		g.mu.Unlock()
+		g.refreshHint()
+		return
+	}
+	pos, moves := g.position, g.moves
+	if g.reviewMove >= len(g.moves) {
+		//caught up with the game, or the reviewed move was taken back
+		g.reviewMove = -1
+	}
+	if g.reviewMove >= 0 {
+		gamedata := *g.gamedata
+		gamedata.Moves = g.moves[:g.reviewMove]
+		if reviewPos, err := positionFromGameData(&gamedata); err == nil {
+			pos, moves = reviewPos, gamedata.Moves
+		} else {
+			g.reviewMove = -1
+		}
+	}
+	state := &api.BoardState{
+		MoveNumber: len(moves),
+		Phase:      g.phase,
+		Outcome:    g.outcome,
+		Board:      pos.Board.Grid(),
+	}
+	if len(g.removed) > 0 && g.reviewMove < 0 {
+		state.Removal = make([][]int, g.position.Board.Height)
+		for y := range state.Removal {
+			state.Removal[y] = make([]int, g.position.Board.Width)
+		}
+		for p := range g.removed {
+			if g.position.Board.OnBoard(p) {
+				state.Removal[p.Y][p.X] = 1
+			}
+		}
+	}
+	state.LastMove.X, state.LastMove.Y = -1, -1
+	if len(moves) > 0 {
+		last := moves[len(moves)-1]
+		state.LastMove.X, state.LastMove.Y = last.X, last.Y
+	}
+	if g.details != nil {
+		state.PlayerToMove = g.details.Players.Black.ID
+		if pos.ToMove == rules.White {
+			state.PlayerToMove = g.details.Players.White.ID
+		}
+	}
+	g.BoardState = state
+	g.mu.Unlock()
+	g.refreshHint()
+}
+
+//positionFromGameData places the initial stones of a game record and plays all of its moves.
+//The moves were accepted by OGS, so only captures are applied; Legal is only used for the user's own moves.
+func positionFromGameData(gamedata *api.GameData) (*rules.Position, error) {
+	opts := rules.OptionsForRuleset(gamedata.Rules)
+	if superko, ok := rules.SuperkoForAlgorithm(gamedata.SuperkoAlgorithm); ok {
+		opts.Superko = superko
+	}
+	pos := rules.NewPosition(gamedata.Width, gamedata.Height, opts)
+	for color, stones := range map[rules.Color]string{rules.Black: gamedata.InitialState.Black, rules.White: gamedata.InitialState.White} {
+		points, err := api.ConvertSGFCoords(stones)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			pos.Place(color, rules.Point{X: p.X, Y: p.Y})
+		}
+	}
+	pos.ToMove = moveColor(gamedata, 0)
+	for i, m := range gamedata.Moves {
+		if _, err := pos.Apply(moveColor(gamedata, i), rules.Point{X: m.X, Y: m.Y}); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+	pos.ToMove = moveColor(gamedata, len(gamedata.Moves))
+	return pos, nil
+}
+
+//moveColor returns the color that plays move i (counting from 0) in the game record.
+func moveColor(gamedata *api.GameData, i int) rules.Color {
+	if gamedata.ColorForMove(i) {
+		return rules.Black
+	}
+	return rules.White
+}
+
+//Reviewing returns true if an earlier move is shown instead of the current position.
+func (g *GoBoardUI) Reviewing() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.reviewMove >= 0
+}
+
+//ReviewBack shows the position one move before the one shown. Moves played in the meantime don't change
+//the position shown, until ReviewEnd is called or ReviewForward reaches the current position.
+func (g *GoBoardUI) ReviewBack() {
+	g.review(func(shown, moves int) int {
+		if shown == 0 {
+			return 0
+		}
+		return shown - 1
+	})
+}
+
+//ReviewForward shows the position one move after the one shown.
+func (g *GoBoardUI) ReviewForward() {
+	g.review(func(shown, moves int) int {
+		return shown + 1
+	})
+}
+
+//ReviewStart shows the position before the first move.
+func (g *GoBoardUI) ReviewStart() {
+	g.review(func(shown, moves int) int {
+		return 0
+	})
+}
+
+//ReviewEnd goes back to following the game.
+func (g *GoBoardUI) ReviewEnd() {
+	g.review(func(shown, moves int) int {
+		return moves
+	})
+}
+
+//review changes the reviewed move to the one returned by f, given the move number that is shown and
+//the number of moves played. Reaching the number of moves played ends reviewing.
+func (g *GoBoardUI) review(f func(shown, moves int) int) {
+	g.mu.Lock()
+	if g.position == nil {
+		g.mu.Unlock()
+		return
+	}
+	shown := g.reviewMove
+	if shown < 0 {
+		shown = len(g.moves)
+	}
+	g.reviewMove = f(shown, len(g.moves))
+	if g.reviewMove >= len(g.moves) {
+		g.reviewMove = -1
+	}
+	g.mu.Unlock()
+	g.updateBoardState()
+}
@@ -2,11 +2,16 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/lvank/termsuji/api"
 	"github.com/lvank/termsuji/config"
+	"github.com/lvank/termsuji/rules"
+	"github.com/lvank/termsuji/sgf"
 	"github.com/mattn/go-runewidth"
 	"github.com/rivo/tview"
 )
@@ -17,18 +22,46 @@ var (
 	yAxis   []string
 )
 
+//removedStone is drawn in place of stones that are marked dead.
+const removedStone = 'x'
+
 type GoBoardUI struct {
-	Box          *tview.Box
-	BoardState   *api.BoardState
-	hint         *tview.TextView
-	cfg          *config.Config
-	finished     bool //BoardState may lag behind a bit; realtime API state is more accurate
-	selX         int
-	selY         int
-	lastTurnPass bool
-	app          *tview.Application
-	rc           *api.RealtimeClient
-	styles       []tcell.Color
+	Box               *tview.Box
+	Clock             *GameClock
+	Chat              *GameChat
+	BoardState        *api.BoardState  //what is drawn; guarded by mu, and replaced as a whole rather than changed
+	details           *api.GameDetails //players, rules, time control etc., loaded once when connecting
+	hint              *tview.TextView
+	cfg               *config.Config
+	selX              int
+	selY              int
+	app               *tview.Application
+	client            *api.Client
+	rc                *api.RealtimeGame
+	gameID            int64
+	mu                sync.Mutex //guards BoardState and the fields below, which are updated from realtime events while drawing
+	finished          bool       //BoardState may lag behind a bit; realtime API state is more accurate
+	winner            int64      //player ID of the winner once the game is finished
+	err               error      //last error from updating the board or playing a move, shown in the hint panel
+	notice            string     //message for the user shown in the hint panel, e.g. after saving the game
+	lastTurnPass      bool
+	gamedata          *api.GameData   //game record the local position was built from
+	position          *rules.Position //current position, kept up to date from move events
+	moves             []api.Move      //all moves played so far
+	phase             string          //phase and outcome from the last gamedata event, copied into BoardState
+	outcome           string
+	reviewMove        int                  //move number shown while reviewing earlier moves, -1 while following the game
+	removed           map[rules.Point]bool //stones marked dead during the stone removal phase
+	removalAcceptedBy int64                //player ID of a player who accepted the current dead stones, 0 if nobody did
+	undoRequested     int                  //move number of a pending undo request, 0 if there is none
+	undoRequestedByMe bool                 //whether the pending undo request was made by the logged in user
+	connErr           error                //set while the connection to the realtime server is down
+	spectating        bool                 //the logged in user isn't playing in this game, so it is shown read-only
+	record            *sgf.Node            //root of the SGF file that is shown instead of an OGS game, see LoadSGF
+	node              *sgf.Node            //current node of record
+	ctx               context.Context      //lives as long as the connection to the current game
+	cancel            context.CancelFunc
+	styles            []tcell.Color
 }
 
 func (g *GoBoardUI) SelectedTile() *api.BoardPos {
@@ -39,25 +72,26 @@ func (g *GoBoardUI) SelectedTile() *api.BoardPos {
 }
 
 func (g *GoBoardUI) MoveSelection(h, v int) {
-	if g.BoardState.Finished() {
+	state := g.boardState()
+	if state.Finished() {
 		g.ResetSelection()
 		return
 	}
 	prevTile := g.SelectedTile()
 	if prevTile == nil {
-		g.selX = g.BoardState.LastMove.X
-		g.selY = g.BoardState.LastMove.Y
+		g.selX = state.LastMove.X
+		g.selY = state.LastMove.Y
 		if g.SelectedTile() == nil {
 			//no previous move made, use board center
-			g.selX = int(g.BoardState.Width() / 2)
-			g.selY = int(g.BoardState.Height() / 2)
+			g.selX = int(state.Width() / 2)
+			g.selY = int(state.Height() / 2)
 		}
 		return
 	}
-	if g.selX+h < 0 || g.selX+h >= g.BoardState.Width() {
+	if g.selX+h < 0 || g.selX+h >= state.Width() {
 		return
 	}
-	if g.selY+v < 0 || g.selY+v >= g.BoardState.Width() {
+	if g.selY+v < 0 || g.selY+v >= state.Width() {
 		return
 	}
 	g.selX += h
@@ -69,26 +103,47 @@ func (g *GoBoardUI) ResetSelection() {
 	g.selY = -1
 }
 
-func NewGoBoard(app *tview.Application, c *config.Config, hint *tview.TextView) *GoBoardUI {
+//boardState returns the BoardState that is currently shown.
+func (g *GoBoardUI) boardState() *api.BoardState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.BoardState
+}
+
+func NewGoBoard(app *tview.Application, client *api.Client, c *config.Config, hint *tview.TextView) *GoBoardUI {
 	goBoard := &GoBoardUI{
 		Box:        tview.NewBox(),
+		Clock:      NewGameClock(app),
+		Chat:       NewGameChat(),
 		BoardState: &api.BoardState{},
 		hint:       hint,
 		app:        app,
+		client:     client,
 		selX:       -1,
 		selY:       -1,
+		reviewMove: -1,
 	}
 	goBoard.SetConfig(c)
+	goBoard.Chat.Input.SetDoneFunc(func(key tcell.Key) {
+		text := goBoard.Chat.Input.GetText()
+		if key == tcell.KeyEnter && text != "" {
+			goBoard.sendChat(text)
+			goBoard.Chat.Input.SetText("")
+			return
+		}
+		app.SetFocus(goBoard.Box)
+	})
 	goBoard.Box.SetDrawFunc(func(screen tcell.Screen, x int, y int, width int, height int) (int, int, int, int) {
-		if goBoard.BoardState == nil {
+		state := goBoard.boardState()
+		if state == nil {
 			return x, y, 1, 1
 		}
 		//To approximate squares, double the width of characters
-		boardW, boardH := goBoard.BoardState.Width()*2, goBoard.BoardState.Height()
+		boardW, boardH := state.Width()*2, state.Height()
 
-		for boardY := 0; boardY < goBoard.BoardState.Height(); boardY++ {
-			for boardX := 0; boardX < goBoard.BoardState.Width(); boardX++ {
-				stone := goBoard.BoardState.Board[boardY][boardX]
+		for boardY := 0; boardY < state.Height(); boardY++ {
+			for boardX := 0; boardX < state.Width(); boardX++ {
+				stone := state.Board[boardY][boardX]
 				i := stone
 				if !goBoard.cfg.Theme.DrawStoneBackground {
 					i = 0
@@ -126,70 +181,394 @@ func NewGoBoard(app *tview.Application, c *config.Config, hint *tview.TextView)
 					//no stone, use cursor color
 					fgColor = goBoard.styles[6]
 				}
+				style := tcell.StyleDefault
+				if stone > 0 && state.RemovedAt(boardX, boardY) {
+					//dead stones are drawn as a dimmed marker on the empty board
+					i = 0
+					if (boardX%2 + boardY%2) == 1 {
+						i = 3
+					}
+					fgColor = goBoard.styles[stone]
+					drawRune = removedStone
+					style = style.Dim(true)
+				}
 				if boardX == goBoard.selX && boardY == goBoard.selY {
 					if goBoard.cfg.Theme.DrawCursorBackground {
 						i = 8
 					} else {
 						drawRune = goBoard.cfg.Theme.Symbols.Cursor
 					}
-				} else if boardX == goBoard.BoardState.LastMove.X && boardY == goBoard.BoardState.LastMove.Y {
+				} else if boardX == state.LastMove.X && boardY == state.LastMove.Y {
 					if goBoard.cfg.Theme.DrawLastPlayedBackground {
 						i = 7
 					} else {
 						drawRune = goBoard.cfg.Theme.Symbols.LastPlayed
 					}
 				}
-				drawCell(screen, tcell.StyleDefault.Background(goBoard.styles[i]).Foreground(fgColor), drawRune, boardX, boardY, x+4, y)
+				drawCell(screen, style.Background(goBoard.styles[i]).Foreground(fgColor), drawRune, boardX, boardY, x+4, y)
 			}
 		}
-		drawCoordinates(screen, x, y, goBoard)
+		drawCoordinates(screen, x, y, goBoard, state)
 		//add offset for coordinate display
 		return x, y, boardW + 4, boardH + 2
 	})
 	return goBoard
 }
 
-func (g *GoBoardUI) Connect(gameID int64) {
+//Connect opens the game with the given ID, joining it on the Client's shared realtime connection.
+//ctx only limits how long connecting may take; the game stays joined until Close is called.
+func (g *GoBoardUI) Connect(ctx context.Context, gameID int64) error {
+	var err error
+	g.gameID = gameID
+	g.mu.Lock()
 	g.finished = false
-	realtimeClient, err := api.Connect(gameID, func(i map[string]interface{}) {
-		if i["phase"] == "finished" {
+	g.winner = 0
+	g.removalAcceptedBy = 0
+	g.undoRequested = 0
+	g.connErr = nil
+	g.err = nil
+	g.notice = ""
+	g.record, g.node = nil, nil
+	g.reviewMove = -1
+	g.BoardState = &api.BoardState{}
+	g.gamedata, g.position, g.moves, g.removed = nil, nil, nil, nil
+	g.phase, g.outcome = "", ""
+	g.lastTurnPass = false
+	g.mu.Unlock()
+	if g.details, err = g.client.GetGameDetails(ctx, gameID); err != nil {
+		return err
+	}
+	g.Clock.Start(g.details.TimeControl, g.details.Players.Black, g.details.Players.White)
+	me := g.client.AuthData.Player.ID
+	g.spectating = g.details.Players.Black.ID != me && g.details.Players.White.ID != me
+	if g.spectating {
+		g.Chat.Clear(api.ChatSpectator)
+	} else {
+		g.Chat.Clear(api.ChatMain, api.ChatMalkovich)
+	}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	//the position is built from the first gamedata event, which OGS sends right after connecting
+	loaded := make(chan struct{})
+	var loadedOnce sync.Once
+	realtimeClient, err := g.client.Realtime(ctx)
+	if err != nil {
+		g.cancel()
+		g.Clock.Stop()
+		return err
+	}
+	game, err := realtimeClient.Join(ctx, gameID, true, func(gamedata *api.GameData) {
+		if gamedata.Clock.LastMove != 0 {
+			g.Clock.Update(gamedata.Clock)
+		}
+		if gamedata.Finished() {
+			g.mu.Lock()
 			g.finished = true
-			g.ResetSelection()
+			g.winner = gamedata.Winner
+			g.mu.Unlock()
+			//the selection belongs to the UI goroutine, which handles the keys that move it
+			g.app.QueueUpdateDraw(g.ResetSelection)
+			g.Clock.SetFinished()
+		}
+		if err := g.loadGameData(gamedata); err != nil {
+			g.resync()
 		}
-		g.refreshBoard()
+		loadedOnce.Do(func() {
+			close(loaded)
+		})
 		g.app.QueueUpdateDraw(func() {})
 	})
-	g.rc = realtimeClient
 	if err != nil {
-		panic(err)
+		g.cancel()
+		g.Clock.Stop()
+		return err
 	}
-	g.rc.Authenticate()
+	g.rc = game
 	g.rc.OnMove(func(m api.OnMoveResult) {
-		//If X/Y are -1, the last turn was a pass.
-		g.lastTurnPass = (m.Move.X == -1 && m.Move.Y == -1)
-		if !g.lastTurnPass {
-			g.refreshBoard()
+		if err := g.applyMove(m); err != nil {
+			g.resync()
+		}
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnRemovedStones(func(r api.OnRemovedStonesResult) {
+		g.mu.Lock()
+		g.removalAcceptedBy = 0
+		g.mu.Unlock()
+		if err := g.setRemoved(r.AllRemoved); err != nil {
+			g.resync()
+		}
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnRemovedStonesAccepted(func(r api.OnRemovedStonesAcceptedResult) {
+		g.mu.Lock()
+		g.removalAcceptedBy = r.PlayerID
+		g.mu.Unlock()
+		g.refreshHint()
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnUndoRequested(func(moveNumber int) {
+		g.mu.Lock()
+		//only the player who made the last move can ask to take it back
+		g.undoRequestedByMe = g.gamedata != nil && moveColor(g.gamedata, moveNumber-1) == g.playerColor()
+		g.undoRequested = moveNumber
+		g.mu.Unlock()
+		g.refreshHint()
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnUndoAccepted(func(moveNumber int) {
+		g.mu.Lock()
+		g.undoRequested = 0
+		g.mu.Unlock()
+		if err := g.undo(moveNumber); err != nil {
+			g.resync()
+		}
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnUndoCanceled(func(int) {
+		g.mu.Lock()
+		g.undoRequested = 0
+		g.mu.Unlock()
+		g.refreshHint()
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnChat(func(c api.OnChatResult) {
+		g.Chat.Add(c)
+		g.app.QueueUpdateDraw(func() {})
+	})
+	g.rc.OnConnectionState(func(state api.ConnectionState, err error) {
+		//after reconnecting, OGS sends the gamedata event again, which brings the position up to date
+		g.mu.Lock()
+		g.connErr = nil
+		if state == api.Reconnecting {
+			g.connErr = err
 		}
+		g.mu.Unlock()
+		g.refreshHint()
 		g.app.QueueUpdateDraw(func() {})
 	})
 	g.rc.OnClock(func(c api.OnClockResult) {
+		g.Clock.Update(c)
 		g.refreshHint()
 	})
-	g.refreshBoard()
+	select {
+	case <-loaded:
+	case <-ctx.Done():
+		g.Close()
+		return ctx.Err()
+	}
+	return nil
 }
 
+//PlayMove plays a move at x, y, or passes if both are -1. Illegal moves are rejected without contacting OGS.
 func (g *GoBoardUI) PlayMove(x, y int) {
-	if g.BoardState.Finished() {
+	if g.spectating || g.boardState().Finished() {
 		return
 	}
-	g.rc.Move(x, y)
+	if g.Reviewing() {
+		g.checkErr(errReviewing)
+		return
+	}
+	if err := g.legal(rules.Point{X: x, Y: y}); err != nil {
+		g.checkErr(err)
+		return
+	}
+	g.setErr(nil)
+	g.checkErr(g.rc.Move(g.ctx, x, y))
+}
+
+//Resign resigns the current game. The caller is responsible for asking for confirmation first.
+func (g *GoBoardUI) Resign() {
+	if g.rc == nil || g.spectating || g.Finished() {
+		return
+	}
+	g.checkErr(g.rc.Resign(g.ctx))
+}
+
+//RequestUndo asks the opponent to take back the last move, which must have been played by the logged in user.
+//If such a request is already pending, it is withdrawn instead.
+func (g *GoBoardUI) RequestUndo() {
+	if g.rc == nil || g.spectating || g.Finished() || g.StoneRemoval() {
+		return
+	}
+	g.mu.Lock()
+	requested, requestedByMe := g.undoRequested, g.undoRequestedByMe
+	moveNumber := len(g.moves)
+	mine := g.gamedata != nil && moveNumber > 0 && moveColor(g.gamedata, moveNumber-1) == g.playerColor()
+	g.mu.Unlock()
+	if requested != 0 {
+		if requestedByMe {
+			g.checkErr(g.rc.CancelUndo(g.ctx, requested))
+		}
+		return
+	}
+	if !mine {
+		g.checkErr(errors.New("You can only undo your own last move"))
+		return
+	}
+	g.checkErr(g.rc.RequestUndo(g.ctx, moveNumber))
+}
+
+//AnswerUndo accepts or ignores the opponent's pending undo request.
+//OGS has no way to decline a request, so ignoring it only dismisses the prompt.
+func (g *GoBoardUI) AnswerUndo(accept bool) {
+	g.mu.Lock()
+	requested, requestedByMe := g.undoRequested, g.undoRequestedByMe
+	g.mu.Unlock()
+	if g.rc == nil || g.spectating || requested == 0 || requestedByMe {
+		return
+	}
+	if accept {
+		g.checkErr(g.rc.AcceptUndo(g.ctx, requested))
+		return
+	}
+	g.mu.Lock()
+	g.undoRequested = 0
+	g.mu.Unlock()
+	g.refreshHint()
+}
+
+//StoneRemoval returns true if the game is in the stone removal phase, where ToggleRemoved,
+//AcceptRemoval and RejectRemoval are used instead of playing moves.
+func (g *GoBoardUI) StoneRemoval() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stoneRemoval()
+}
+
+//stoneRemoval is StoneRemoval for callers that hold mu.
+func (g *GoBoardUI) stoneRemoval() bool {
+	return g.BoardState.Phase == "stone removal"
+}
+
+//Spectating returns true if the logged in user isn't one of the players of the current game.
+//The game is shown read-only then: moves, resigning, undos and marking dead stones are ignored.
+func (g *GoBoardUI) Spectating() bool {
+	return g.spectating
+}
+
+//GameID returns the ID of the game that is shown.
+func (g *GoBoardUI) GameID() int64 {
+	return g.gameID
+}
+
+//SGF returns the record of the current game as far as it is known locally, with the chat as comments.
+func (g *GoBoardUI) SGF() (*sgf.Node, error) {
+	g.mu.Lock()
+	if g.details == nil || g.gamedata == nil {
+		g.mu.Unlock()
+		return nil, errors.New("The game hasn't been loaded yet")
+	}
+	details := *g.details
+	details.GameData = *g.gamedata
+	details.GameData.Moves = append([]api.Move(nil), g.moves...)
+	g.mu.Unlock()
+	return details.SGF(g.Chat.Lines())
+}
+
+//Notify shows a message in the hint panel, until another game is opened.
+func (g *GoBoardUI) Notify(text string) {
+	g.mu.Lock()
+	g.notice = text
+	g.mu.Unlock()
+	g.refreshHint()
+}
+
+//ToggleRemoved marks the group at x, y as dead, or as alive if it was already marked dead.
+func (g *GoBoardUI) ToggleRemoved(x, y int) {
+	if g.spectating || !g.StoneRemoval() {
+		return
+	}
+	if g.Reviewing() {
+		g.checkErr(errReviewing)
+		return
+	}
+	g.mu.Lock()
+	if g.position == nil {
+		g.mu.Unlock()
+		return
+	}
+	group := g.position.Board.Group(rules.Point{X: x, Y: y})
+	removed := len(group) > 0 && !g.removed[group[0]]
+	g.mu.Unlock()
+	if len(group) == 0 {
+		return
+	}
+	stones := make([]api.BoardPos, len(group))
+	for i, p := range group {
+		stones[i] = api.BoardPos{X: p.X, Y: p.Y}
+	}
+	g.checkErr(g.rc.SetRemovedStones(g.ctx, stones, removed))
+}
+
+//AcceptRemoval accepts the stones currently marked as dead.
+func (g *GoBoardUI) AcceptRemoval() {
+	if g.spectating || !g.StoneRemoval() {
+		return
+	}
+	g.checkErr(g.rc.AcceptRemovedStones(g.ctx, g.removedStones()))
+}
+
+//RejectRemoval rejects the stones currently marked as dead and resumes the game.
+func (g *GoBoardUI) RejectRemoval() {
+	if g.spectating || !g.StoneRemoval() {
+		return
+	}
+	g.checkErr(g.rc.RejectRemovedStones(g.ctx))
+}
+
+//FocusChat moves the input focus to the chat input line. Pressing Esc there, or Return on an empty line, moves it back.
+func (g *GoBoardUI) FocusChat() {
+	g.app.SetFocus(g.Chat.Input)
+}
+
+//sendChat sends a message to the chat channel selected in the chat input line.
+func (g *GoBoardUI) sendChat(text string) {
+	if g.rc == nil {
+		return
+	}
+	g.mu.Lock()
+	moveNumber := len(g.moves)
+	g.mu.Unlock()
+	g.checkErr(g.rc.SendChat(g.ctx, g.Chat.Channel(), moveNumber, text))
+}
+
+//checkErr shows err in the hint panel, if it is not nil.
+func (g *GoBoardUI) checkErr(err error) {
+	if err != nil {
+		g.setErr(err)
+	}
+}
+
+//setErr replaces the error shown in the hint panel; nil clears it.
+func (g *GoBoardUI) setErr(err error) {
+	g.mu.Lock()
+	g.err = err
+	g.mu.Unlock()
+	g.refreshHint()
+}
+
+//Finished returns true if the current game has ended.
+func (g *GoBoardUI) Finished() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.finished
+}
+
+//playerColor returns the color of the logged in user in the current game.
+func (g *GoBoardUI) playerColor() rules.Color {
+	if g.details != nil && g.details.Players.White.ID == g.client.AuthData.Player.ID {
+		return rules.White
+	}
+	return rules.Black
 }
 
 func (g *GoBoardUI) Close() {
 	if g.rc == nil {
 		return
 	}
-	g.rc.Disconnect()
+	g.cancel()
+	g.Clock.Stop()
+	g.rc.Leave()
+	g.rc = nil
 }
 
 func (g *GoBoardUI) SetConfig(c *config.Config) {
@@ -207,26 +586,95 @@ func (g *GoBoardUI) SetConfig(c *config.Config) {
 	g.cfg = c
 }
 
-func (g *GoBoardUI) refreshBoard() {
-	g.BoardState = api.GetGameState(g.rc.GameID)
-	g.refreshHint()
+func (g *GoBoardUI) refreshHint() {
+	g.mu.Lock()
+	text := g.hintText()
+	g.mu.Unlock()
+	g.hint.SetText(text)
 }
 
-func (g *GoBoardUI) refreshHint() {
-	var passHint, turnHint string
+//hintText returns the text of the hint panel for the current state of the game. mu must be held.
+func (g *GoBoardUI) hintText() string {
+	if g.record != nil {
+		return g.viewerHint()
+	}
+	var infoHint, errHint, passHint, turnHint string
+	if g.connErr != nil {
+		infoHint = fmt.Sprintf("OFFLINE, reconnecting... (%s)\n\n", g.connErr)
+	}
+	if g.details != nil {
+		infoHint += fmt.Sprintf("%s\nMove: %d\n\n", g.details.Description(), g.BoardState.MoveNumber)
+	}
+	if g.reviewMove >= 0 {
+		infoHint = fmt.Sprintf("== VIEWING MOVE %d/%d ==\nEnd: back to the game\n\n", g.reviewMove, len(g.moves)) + infoHint
+	}
+	if g.err != nil {
+		errHint = fmt.Sprintf("Error: %s\n\n", g.err)
+	}
+	if g.notice != "" {
+		errHint += g.notice + "\n\n"
+	}
+	if g.spectating {
+		switch {
+		case g.finished:
+			turnHint = fmt.Sprintf("The game is over.\n%sOutcome: %s", g.winnerHint(), g.BoardState.Outcome)
+		case g.stoneRemoval():
+			turnHint = "The players are marking dead stones."
+		case g.details != nil && g.BoardState.PlayerToMove == g.details.Players.White.ID:
+			turnHint = fmt.Sprintf("It is %s's (white) turn.", g.details.Players.White.Username)
+		case g.details != nil:
+			turnHint = fmt.Sprintf("It is %s's (black) turn.", g.details.Players.Black.Username)
+		}
+		if g.lastTurnPass && !g.finished {
+			passHint = "The previous turn was passed.\n\n"
+		}
+		return fmt.Sprintf("%s%s%sYou are spectating this game.\n%s\n\narrow keys: move cursor\n,/.: previous/next move\nHome/End: first/current move\nc: chat\ne: save as SGF\nq: quit", infoHint, errHint, passHint, turnHint)
+	}
 	if g.finished {
-		turnHint = fmt.Sprintf("The game is over.\nOutcome: %s", g.BoardState.Outcome)
+		turnHint = fmt.Sprintf("The game is over.\n%sOutcome: %s", g.winnerHint(), g.BoardState.Outcome)
+	} else if g.stoneRemoval() {
+		turnHint = "Stone removal: mark dead stones, then accept when you agree with the result."
+		switch g.removalAcceptedBy {
+		case 0:
+		case g.client.AuthData.Player.ID:
+			turnHint += "\nYou have accepted the dead stones."
+		default:
+			turnHint += "\nYour opponent has accepted the dead stones."
+		}
+		return fmt.Sprintf("%s%s%s\n\narrow keys: move cursor\nReturn: mark group dead/alive\na: accept dead stones\nx: reject and resume game\nq: quit", infoHint, errHint, turnHint)
 	} else {
 		if g.lastTurnPass {
 			passHint = "The previous turn was passed.\n\n"
 		}
-		if g.BoardState.PlayerToMove == api.AuthData.Player.ID {
+		switch {
+		case g.undoRequested == 0:
+		case g.undoRequestedByMe:
+			passHint += "You requested an undo. u: cancel request\n\n"
+		default:
+			passHint += "Your opponent requested an undo.\ny: accept / n: ignore\n\n"
+		}
+		if g.BoardState.PlayerToMove == g.client.AuthData.Player.ID {
 			turnHint = "It is your turn."
 		} else {
 			turnHint = "It is your opponent's turn."
 		}
 	}
-	g.hint.SetText(fmt.Sprintf("%s%s\n\narrow keys: move cursor\nReturn: play move\np: pass turn\nq: quit", passHint, turnHint))
+	return fmt.Sprintf("%s%s%s%s\n\narrow keys: move cursor\nReturn: play move\np: pass turn\nu: request undo\nR: resign\n,/.: previous/next move\nHome/End: first/current move\nc: chat\ne: save as SGF\nq: quit", infoHint, errHint, passHint, turnHint)
+}
+
+//winnerHint returns a line announcing the winner, if known. mu must be held.
+func (g *GoBoardUI) winnerHint() string {
+	switch {
+	case g.winner == 0 || g.details == nil:
+		return ""
+	case g.winner == g.client.AuthData.Player.ID:
+		return "You won!\n"
+	case g.winner == g.details.Players.Black.ID:
+		return fmt.Sprintf("%s (black) won.\n", g.details.Players.Black.Username)
+	case g.winner == g.details.Players.White.ID:
+		return fmt.Sprintf("%s (white) won.\n", g.details.Players.White.Username)
+	}
+	return ""
 }
 
 // Helper function to draw a single cell, which occupies two characters on screen
@@ -239,9 +687,9 @@ func drawCell(s tcell.Screen, c tcell.Style, r rune, x, y, l, t int) {
 	}
 }
 
-func drawCoordinates(s tcell.Screen, x, y int, ui *GoBoardUI) {
+func drawCoordinates(s tcell.Screen, x, y int, ui *GoBoardUI, state *api.BoardState) {
 	hCoord := int('A')
-	w, h := ui.BoardState.Width(), ui.BoardState.Height()
+	w, h := state.Width(), state.Height()
 	if ui.cfg.Theme.FullWidthLetters {
 		hCoord = int('Ａ')
 	}
@@ -254,7 +702,7 @@ func drawCoordinates(s tcell.Screen, x, y int, ui *GoBoardUI) {
 		_style := style
 		if ix == ui.selX {
 			_style = highlight
-		} else if ix == ui.BoardState.LastMove.X {
+		} else if ix == state.LastMove.X {
 			_style = lpHighlight
 		}
 		s.SetContent(x+4+(ix*2), y+h+1, rune(hCoord+ix), nil, _style)
@@ -266,7 +714,7 @@ func drawCoordinates(s tcell.Screen, x, y int, ui *GoBoardUI) {
 		_style := style
 		if iyInv == ui.selY {
 			_style = highlight
-		} else if iyInv == ui.BoardState.LastMove.Y {
+		} else if iyInv == state.LastMove.Y {
 			_style = lpHighlight
 		}
 		displayNum := iy + 1
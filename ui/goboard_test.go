@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/api"
+	"github.com/lvank/termsuji/config"
+	"github.com/rivo/tview"
+)
+
+//TestDrawRemovedStones draws a board with stones marked dead using every preset theme,
+//including those that don't draw stone backgrounds.
+func TestDrawRemovedStones(t *testing.T) {
+	themes := map[string]config.Theme{
+		"default": config.DefaultTheme,
+		"unicode": config.UnicodeTheme,
+		"catdog":  config.CatdogTheme,
+		"hongoku": config.HongokuTheme,
+	}
+	for name, theme := range themes {
+		screen := tcell.NewSimulationScreen("UTF-8")
+		if err := screen.Init(); err != nil {
+			t.Fatal(err)
+		}
+		screen.SetSize(80, 30)
+		cfg := config.DefaultConfig
+		cfg.Theme = theme
+		g := NewGoBoard(tview.NewApplication(), api.NewClient(), &cfg, tview.NewTextView())
+		g.BoardState = &api.BoardState{
+			Phase: "stone removal",
+			Board: [][]int{
+				{1, 2, 0},
+				{2, 1, 0},
+				{0, 0, 0},
+			},
+			Removal: [][]int{
+				{1, 1, 0},
+				{1, 1, 0},
+				{0, 0, 0},
+			},
+		}
+		g.BoardState.LastMove.X, g.BoardState.LastMove.Y = -1, -1
+		g.Box.SetRect(0, 0, 80, 30)
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s theme: drawing dead stones panicked: %v", name, r)
+				}
+			}()
+			g.Box.Draw(screen)
+		}()
+		//the dead black stone at the upper left corner is drawn as a marker
+		if r, _, _, _ := screen.GetContent(4, 0); r != removedStone {
+			t.Errorf("%s theme: expected %q for a dead stone, got %q", name, removedStone, r)
+		}
+		screen.Fini()
+	}
+}
+
+//TestDrawWhileUpdating draws the board while realtime events update it, for the race detector to check.
+func TestDrawWhileUpdating(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 30)
+	cfg := config.DefaultConfig
+	g := NewGoBoard(tview.NewApplication(), api.NewClient(), &cfg, tview.NewTextView())
+	g.Box.SetRect(0, 0, 80, 30)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if err := g.setGameData(&api.GameData{Width: 9, Height: 9, Moves: []api.Move{{X: i % 9, Y: i / 9}}}); err != nil {
+				t.Error(err)
+				return
+			}
+			g.Notify("saved")
+			g.checkErr(errReviewing)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			g.Box.Draw(screen)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lvank/termsuji/api"
+	"github.com/lvank/termsuji/rules"
+	"github.com/lvank/termsuji/sgf"
+)
+
+//markupNames are the SGF markup properties listed in the hint panel, with their descriptions.
+var markupNames = []struct{ id, name string }{
+	{"TR", "Triangles"},
+	{"SQ", "Squares"},
+	{"CR", "Circles"},
+	{"MA", "Crosses"},
+}
+
+//LoadSGF shows a game record from an SGF file instead of an OGS game, starting at its first node.
+//Nothing is sent to OGS in this mode; the record is browsed with NextNode, PrevNode and NextVariation.
+func (g *GoBoardUI) LoadSGF(root *sgf.Node) error {
+	width, height, err := root.Size()
+	if err != nil {
+		return err
+	}
+	if width > 25 || height > 25 {
+		return fmt.Errorf("Boards larger than 25x25 can't be shown (this one is %dx%d)", width, height)
+	}
+	g.mu.Lock()
+	g.record = root
+	g.mu.Unlock()
+	g.details = nil
+	g.rc = nil
+	g.spectating = true
+	g.ResetSelection()
+	g.showNode(root)
+	return nil
+}
+
+//Viewing returns true if an SGF file is shown, see LoadSGF.
+func (g *GoBoardUI) Viewing() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.record != nil
+}
+
+//viewed returns the root of the SGF file that is shown and its current node, both nil if none is shown.
+func (g *GoBoardUI) viewed() (record, node *sgf.Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.record, g.node
+}
+
+//NextNode goes forward one move, following the main line of the current variation.
+func (g *GoBoardUI) NextNode() {
+	if _, node := g.viewed(); node != nil && len(node.Children) > 0 {
+		g.showNode(node.Children[0])
+	}
+}
+
+//PrevNode goes back one move.
+func (g *GoBoardUI) PrevNode() {
+	if _, node := g.viewed(); node != nil && node.Parent != nil {
+		g.showNode(node.Parent)
+	}
+}
+
+//FirstNode goes back to the start of the game record.
+func (g *GoBoardUI) FirstNode() {
+	if record, _ := g.viewed(); record != nil {
+		g.showNode(record)
+	}
+}
+
+//LastNode goes forward to the end of the current variation.
+func (g *GoBoardUI) LastNode() {
+	_, n := g.viewed()
+	if n == nil {
+		return
+	}
+	for len(n.Children) > 0 {
+		n = n.Children[0]
+	}
+	g.showNode(n)
+}
+
+//NextVariation replaces the current move with the next alternative for it, if the record has any.
+func (g *GoBoardUI) NextVariation() {
+	_, node := g.viewed()
+	if node == nil || node.Parent == nil {
+		return
+	}
+	siblings := node.Parent.Children
+	for i, n := range siblings {
+		if n == node {
+			g.showNode(siblings[(i+1)%len(siblings)])
+			return
+		}
+	}
+}
+
+//showNode makes n the current node, and shows the position after playing all moves leading up to it.
+func (g *GoBoardUI) showNode(n *sgf.Node) {
+	var path []*sgf.Node
+	for p := n; p != nil; p = p.Parent {
+		path = append([]*sgf.Node{p}, path...)
+	}
+	width, height, _ := n.Size()
+	pos := rules.NewPosition(width, height, rules.OptionsForRuleset(sgfRuleset(n.Root().Get("RU"))))
+	state := &api.BoardState{}
+	state.LastMove.X, state.LastMove.Y = -1, -1
+	var lastErr error
+	for _, node := range path {
+		if err := applySetup(pos, node); err != nil {
+			lastErr = err
+		}
+		m, err := node.Move()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if m == nil {
+			continue
+		}
+		color := rules.White
+		if m.Black {
+			color = rules.Black
+		}
+		pt := rules.Point{X: m.X, Y: m.Y}
+		if _, err := pos.PlayAs(color, pt); err != nil {
+			//files may contain illegal moves, show them anyway
+			lastErr = fmt.Errorf("move %d: %w", state.MoveNumber+1, err)
+			pos.Apply(color, pt)
+		}
+		state.MoveNumber++
+		state.LastMove.X, state.LastMove.Y = m.X, m.Y
+	}
+	state.Board = pos.Board.Grid()
+	g.mu.Lock()
+	g.err = lastErr
+	g.node = n
+	g.lastTurnPass = state.MoveNumber > 0 && state.LastMove.X < 0
+	g.BoardState = state
+	g.mu.Unlock()
+	g.refreshHint()
+}
+
+//applySetup adds and removes the stones of the setup properties of n, and sets the player to move.
+func applySetup(pos *rules.Position, n *sgf.Node) error {
+	for _, setup := range []struct {
+		id    string
+		color rules.Color
+	}{{"AE", rules.Empty}, {"AB", rules.Black}, {"AW", rules.White}} {
+		points, err := n.Points(setup.id)
+		if err != nil {
+			return err
+		}
+		for _, p := range points {
+			if pt := (rules.Point{X: p.X, Y: p.Y}); pos.Board.OnBoard(pt) {
+				pos.Place(setup.color, pt)
+			}
+		}
+	}
+	switch n.Get("PL") {
+	case "B":
+		pos.ToMove = rules.Black
+	case "W":
+		pos.ToMove = rules.White
+	}
+	return nil
+}
+
+//sgfRuleset converts the RU property of an SGF file to an OGS ruleset name.
+func sgfRuleset(ru string) string {
+	if strings.EqualFold(ru, "GOE") {
+		return "ing"
+	}
+	return strings.ToLower(ru)
+}
+
+//viewerHint returns the text of the hint panel while viewing an SGF file: the game information,
+//the current move with its comment and markup, and the keys. mu must be held.
+func (g *GoBoardUI) viewerHint() string {
+	var sb strings.Builder
+	root := g.record
+	player := func(name, rank string) string {
+		if name == "" {
+			name = "?"
+		}
+		if rank != "" {
+			return fmt.Sprintf("%s (%s)", name, rank)
+		}
+		return name
+	}
+	if name := root.Get("GN"); name != "" {
+		sb.WriteString(name + "\n")
+	}
+	fmt.Fprintf(&sb, "Black: %s\nWhite: %s\n", player(root.Get("PB"), root.Get("BR")), player(root.Get("PW"), root.Get("WR")))
+	var info []string
+	for _, prop := range []struct{ id, name string }{{"RU", "rules"}, {"KM", "komi"}, {"HA", "handicap"}, {"DT", "date"}, {"RE", "result"}} {
+		if v := root.Get(prop.id); v != "" {
+			info = append(info, fmt.Sprintf("%s %s", prop.name, v))
+		}
+	}
+	if len(info) > 0 {
+		sb.WriteString(strings.Join(info, ", ") + "\n")
+	}
+	sb.WriteString("\n")
+	if g.err != nil {
+		fmt.Fprintf(&sb, "Error: %s\n\n", g.err)
+	}
+	n := g.node
+	height := g.BoardState.Height()
+	if m, _ := n.Move(); m != nil {
+		color := "White"
+		if m.Black {
+			color = "Black"
+		}
+		move := "pass"
+		if !m.IsPass() {
+			move = pointName(sgf.Point{X: m.X, Y: m.Y}, height)
+		}
+		fmt.Fprintf(&sb, "Move %d: %s %s\n", g.BoardState.MoveNumber, color, move)
+	} else {
+		fmt.Fprintf(&sb, "Move %d\n", g.BoardState.MoveNumber)
+	}
+	if n.Parent != nil && len(n.Parent.Children) > 1 {
+		for i, sibling := range n.Parent.Children {
+			if sibling == n {
+				fmt.Fprintf(&sb, "Variation %d of %d\n", i+1, len(n.Parent.Children))
+			}
+		}
+	}
+	if len(n.Children) > 1 {
+		fmt.Fprintf(&sb, "%d variations follow\n", len(n.Children))
+	}
+	for _, markup := range markupNames {
+		points, _ := n.Points(markup.id)
+		if len(points) == 0 {
+			continue
+		}
+		names := make([]string, len(points))
+		for i, p := range points {
+			names[i] = pointName(p, height)
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", markup.name, strings.Join(names, ", "))
+	}
+	if labels, _ := n.Labels(); len(labels) > 0 {
+		names := make([]string, len(labels))
+		for i, l := range labels {
+			names[i] = fmt.Sprintf("%s at %s", l.Text, pointName(l.Point, height))
+		}
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(names, ", "))
+	}
+	if comment := n.Get("C"); comment != "" {
+		fmt.Fprintf(&sb, "\n%s\n", comment)
+	}
+	sb.WriteString("\nleft/[: previous move\nright/]: next move\nTab: next variation\nHome/End: first/last move\nq: quit")
+	return sb.String()
+}
+
+//pointName returns the name of a point as shown by the board coordinates, e.g. D4.
+func pointName(p sgf.Point, height int) string {
+	return fmt.Sprintf("%c%d", 'A'+p.X, height-p.Y)
+}
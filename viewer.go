@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lvank/termsuji/config"
+	"github.com/lvank/termsuji/sgf"
+	"github.com/lvank/termsuji/ui"
+	"github.com/rivo/tview"
+)
+
+//The viewer shows a local SGF file on the board, without the rest of termsuji: there's no login, game list or clock.
+//Only the first game of a file with several games is shown.
+
+//viewCommand opens the SGF file named by args in the viewer.
+func viewCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New(usage)
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	roots, err := sgf.Parse(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	//a broken configuration file falls back to the default theme, as in the full interface
+	cfg, _ := config.InitConfig()
+	app = tview.NewApplication()
+	hint := tview.NewTextView().SetWrap(true).SetWordWrap(true)
+	hint.SetBorder(true)
+	board := ui.NewGoBoard(app, nil, cfg, hint)
+	if err := board.LoadSGF(roots[0]); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	board.Box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			board.PrevNode()
+		case tcell.KeyRight:
+			board.NextNode()
+		case tcell.KeyHome:
+			board.FirstNode()
+		case tcell.KeyEnd:
+			board.LastNode()
+		case tcell.KeyTab:
+			board.NextVariation()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case '[':
+				board.PrevNode()
+			case ']':
+				board.NextNode()
+			case 'q':
+				app.Stop()
+			default:
+				return event
+			}
+		default:
+			return event
+		}
+		return nil
+	})
+	layout := tview.NewFlex().
+		AddItem(board.Box, 20*2+3, 1, true).
+		AddItem(hint, 0, 2, false)
+	layout.SetBorder(true).SetTitle(fmt.Sprintf("termsuji - %s", filepath.Base(args[0])))
+	return app.SetRoot(layout, true).Run()
+}